@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mejzh77/astragen/configs/config"
+	"github.com/mejzh77/astragen/internal/api"
+	"github.com/mejzh77/astragen/internal/database"
+	"github.com/mejzh77/astragen/internal/sync"
+	"gorm.io/gorm"
+)
+
+// errValidation - validate нашел ошибки (код выхода 1 без сообщения log.Fatal)
+var errValidation = errors.New("validation failed")
+
+// options - общие флаги команд
+type options struct {
+	configPath  string
+	credentials string
+}
+
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&opts.configPath, "config", "config.yml", "файл конфига")
+	fs.StringVar(&opts.credentials, "credentials", "", "ключ сервисного аккаунта Google (по умолчанию из конфига или credentials.json)")
+	return fs
+}
+
+// open загружает конфиг и подключается к БД; clean очищает таблицы перед миграцией
+func (o *options) open(clean bool) (*sync.SyncService, *gorm.DB, error) {
+	log.Println("Loading configuration...")
+	if err := config.CreateDefaultConfigIfNotExist(o.configPath); err != nil {
+		return nil, nil, fmt.Errorf("failed to create default config: %w", err)
+	}
+	cfg := config.LoadConfig(o.configPath)
+	if cfg.DB == nil {
+		return nil, nil, fmt.Errorf("db section is missing in %s", o.configPath)
+	}
+
+	log.Println("Initializing database connection...")
+	db, err := database.InitDB(cfg.DB.DriverName(), cfg.DB.DSN(), clean)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database initialization failed: %w", err)
+	}
+	syncService := sync.NewSyncService(nil, db)
+	syncService.SetCredentialsPath(o.credentials)
+	return syncService, db, nil
+}
+
+// writeOutput записывает результат в файл path или в stdout, если path пуст или "-"
+func writeOutput(path, content string) error {
+	if path == "" || path == "-" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func runServe(args []string) error {
+	var opts options
+	fs := newFlagSet("serve", &opts)
+	addr := fs.String("addr", ":8080", "адрес веб-сервера")
+	watch := fs.Duration("watch", 2*time.Second, "период проверки изменений файла конфига (0 - не следить)")
+	fs.Parse(args)
+
+	syncService, db, err := opts.open(true)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+	// Правки файла, сделанные при остановленном сервере, попадают в историю версий
+	if _, err := syncService.RecordConfigVersion(config.Get(), "startup", "loaded at startup"); err != nil {
+		log.Printf("Failed to record config version: %v", err)
+	}
+	if config.Get().Update {
+		if err := syncService.RunFullSync(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	webService := api.NewWebService(syncService)
+	webService.RegisterRoutes()
+	if *watch > 0 {
+		go webService.WatchConfig(context.Background(), *watch)
+	}
+	log.Printf("Starting server on %s", *addr)
+	webService.Run(*addr)
+	return nil
+}
+
+func runSync(args []string) error {
+	var opts options
+	newFlagSet("sync", &opts).Parse(args)
+
+	syncService, db, err := opts.open(true)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+	return syncService.RunFullSync(context.Background())
+}
+
+func runGenerate(args []string) error {
+	var opts options
+	fs := newFlagSet("generate", &opts)
+	fileType := fs.String("type", "", "тип файла: STDecl, ST, STInit, OMX, OPC, InterlocksDecl, Interlocks")
+	system := fs.String("system", "", "система")
+	node := fs.String("node", "", "узел")
+	cdsType := fs.String("cds-type", "", "тип ФБ")
+	out := fs.String("out", "", "файл результата (по умолчанию stdout)")
+	fs.Parse(args)
+	if *fileType == "" {
+		fs.Usage()
+		return fmt.Errorf("--type is required")
+	}
+
+	syncService, db, err := opts.open(false)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	file, err := syncService.GenerateImportFile(*system, *cdsType, *node, *fileType)
+	if err != nil {
+		return err
+	}
+	if file.Cycle != "" {
+		log.Printf("Warning: %s", file.Cycle)
+	}
+	for _, fb := range file.Incomplete {
+		log.Printf("Warning: FB %s (%s) is incomplete: missing in %v, out %v", fb.Tag, fb.CdsType, fb.MissingIn, fb.MissingOut)
+	}
+	log.Printf("Generated %s for %d items", *fileType, file.Count)
+	return writeOutput(*out, file.Content)
+}
+
+func runExport(args []string) error {
+	var opts options
+	fs := newFlagSet("export", &opts)
+	exportType := fs.String("type", "", "выгрузка: alarms, archive, modbus")
+	format := fs.String("format", "", "формат: для alarms - имя из alarms.formats, для modbus - csv или json")
+	system := fs.String("system", "", "система")
+	node := fs.String("node", "", "узел (modbus)")
+	out := fs.String("out", "", "файл результата (по умолчанию stdout)")
+	fs.Parse(args)
+
+	syncService, db, err := opts.open(false)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	var content string
+	var count int
+	switch *exportType {
+	case "alarms":
+		content, count, err = syncService.ExportAlarms(*system, *format)
+	case "archive":
+		content, _, count, err = syncService.ExportArchive(*system)
+	case "modbus":
+		if *format == "" {
+			*format = "csv"
+		}
+		content, count, err = syncService.ExportModbus(*system, *node, *format)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown export type %q", *exportType)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Exported %d %s rows", count, *exportType)
+	return writeOutput(*out, content)
+}
+
+func runValidate(args []string) error {
+	var opts options
+	fs := newFlagSet("validate", &opts)
+	system := fs.String("system", "", "система")
+	fs.Parse(args)
+
+	syncService, db, err := opts.open(false)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	problems := 0
+	incomplete, err := syncService.GetFBCompletenessReport(*system, "", "")
+	if err != nil {
+		return err
+	}
+	for _, fb := range incomplete {
+		fmt.Printf("FB %s (%s, %s): missing in %v, out %v\n", fb.Tag, fb.CdsType, fb.Node, fb.MissingIn, fb.MissingOut)
+	}
+	problems += len(incomplete)
+
+	tagIssues, err := syncService.GetFBTagReport()
+	if err != nil {
+		return err
+	}
+	for _, issue := range tagIssues {
+		fmt.Printf("Tag %s (%s): %s\n", issue.SignalTag, issue.CdsType, issue.Reason)
+	}
+	problems += len(tagIssues)
+
+	_, moduleIssues, err := syncService.GetModuleReport(*system)
+	if err != nil {
+		return err
+	}
+	for _, issue := range moduleIssues {
+		fmt.Printf("Signal %s (module %s, channel %s): %s\n", issue.SignalTag, issue.Module, issue.Channel, issue.Reason)
+	}
+	problems += len(moduleIssues)
+
+	_, modbusIssues, err := syncService.GetModbusMap(*system, "")
+	if err != nil {
+		return err
+	}
+	for _, issue := range modbusIssues {
+		fmt.Printf("Modbus %s %s %d (%v): %s\n", issue.Node, issue.Type, issue.Address, issue.Tags, issue.Reason)
+	}
+	problems += len(modbusIssues)
+
+	if problems > 0 {
+		fmt.Printf("%d problems found\n", problems)
+		return errValidation
+	}
+	fmt.Println("No problems found")
+	return nil
+}
+
+func runMigrate(args []string) error {
+	var opts options
+	newFlagSet("migrate", &opts).Parse(args)
+
+	_, db, err := opts.open(false)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+	log.Println("Database schema is up to date")
+	return nil
+}
@@ -1,59 +1,69 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"github.com/mejzh77/astragen/configs/config"
-	"github.com/mejzh77/astragen/internal/api"
-	"github.com/mejzh77/astragen/internal/database"
-	"github.com/mejzh77/astragen/internal/sync"
-	"gorm.io/gorm"
 	"log"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
 )
 
+const usage = `Использование: astragen <команда> [флаги]
+
+Команды:
+  serve     веб-интерфейс (по умолчанию); синхронизация при запуске, если update: true
+  sync      полная синхронизация с Google Sheets
+  generate  файл импорта: --type STDecl|ST|STInit|OMX|OPC|InterlocksDecl|Interlocks
+  export    выгрузка: --type alarms|archive|modbus
+  validate  проверка данных: неполные ФБ, тэги, каналы модулей, карта Modbus
+  migrate   миграция схемы БД без очистки данных
+
+Общие флаги:
+  --config       файл конфига (по умолчанию config.yml)
+  --credentials  ключ сервисного аккаунта Google (по умолчанию credentials/credentials_file
+                 из конфига или credentials.json)
+
+Поля конфига переопределяются переменными окружения ASTRAGEN_<ПУТЬ>, например
+ASTRAGEN_DB_PASSWORD; секреты задаются ссылками ${env:NAME} или ${file:PATH}.
+
+Флаги команды: astragen <команда> -h
+`
+
 func main() {
-	// 1. Загрузка конфигурации
-	log.Println("Loading configuration...")
-	err := config.CreateDefaultConfigIfNotExist("config.yml")
-	if err != nil {
-		log.Fatalf("Failed to create default config: %v", err)
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
-	config.Cfg = config.LoadConfig("config.yml")
 
-	// 2. Инициализация БД
-	log.Println("Initializing database connection...")
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s client_encoding=UTF8",
-		config.Cfg.DB.Host,
-		config.Cfg.DB.User,
-		config.Cfg.DB.Password,
-		config.Cfg.DB.Name,
-		config.Cfg.DB.Port,
-		config.Cfg.DB.SSLMode,
-	)
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(args)
+	case "sync":
+		err = runSync(args)
+	case "generate":
+		err = runGenerate(args)
+	case "export":
+		err = runExport(args)
+	case "validate":
+		err = runValidate(args)
+	case "migrate":
+		err = runMigrate(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "неизвестная команда %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
 
-	db, err := database.InitDB(dsn, true)
-	if err != nil {
-		log.Fatalf("Database initialization failed: %v", err)
+	if errors.Is(err, errValidation) {
+		os.Exit(1)
 	}
-	defer closeDB(db)
-	syncService := sync.NewSyncService(nil, db)
-	ctx := context.Background()
-	if config.Cfg.Update {
-		err := syncService.RunFullSync(ctx)
-		if err != nil {
-			log.Fatal(err)
-		}
+	if err != nil {
+		log.Fatal(err)
 	}
-	webService := api.NewWebService(syncService)
-
-	// Добавляем функцию для шаблонов
-	//webService.SetupTemplates()
-	webService.RegisterRoutes()
-
-	// Запуск сервера
-	log.Println("Starting server on :8080")
-	webService.Run(":8080")
 }
 
 func closeDB(db *gorm.DB) {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 
@@ -8,63 +9,278 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var Cfg *AppConfig
+// Path - файл, из которого загружен конфиг; в него сохраняются изменения и
+// за ним следит Watch
+var Path = "config.yml"
 
 type DatabaseConfig struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Name     string `yaml:"name"`
-	SSLMode  string `yaml:"ssl_mode"` // "disable" или "require"
+	Driver   string `yaml:"driver,omitempty" json:"driver,omitempty"` // "postgres" (по умолчанию) или "sqlite"
+	Host     string `yaml:"host" json:"host"`
+	Port     string `yaml:"port" json:"port"`
+	User     string `yaml:"user" json:"user"`
+	Password string `yaml:"password" json:"password" secret:"true"` // Значение или ссылка ${env:NAME}, ${file:PATH}
+	Name     string `yaml:"name" json:"name"`
+	SSLMode  string `yaml:"ssl_mode" json:"ssl_mode"`             // "disable" или "require"
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"` // Файл SQLite или ":memory:"
+}
+
+// DriverName возвращает драйвер БД, по умолчанию postgres
+func (c *DatabaseConfig) DriverName() string {
+	if c.Driver == "" {
+		return "postgres"
+	}
+	return c.Driver
+}
+
+// DSN возвращает строку подключения для драйвера
+func (c *DatabaseConfig) DSN() string {
+	if c.DriverName() == "sqlite" {
+		if c.Path == "" {
+			return "astragen.db"
+		}
+		return c.Path
+	}
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s client_encoding=UTF8",
+		c.Host, c.User, c.Password, c.Name, c.Port, c.SSLMode,
+	)
 }
 
 type SheetConfig struct {
-	SheetName  string      `yaml:"sheet_name"`
-	SignalType string      `yaml:"signal_type"` // "DI", "AI", "DQ", "AQ"
-	Model      interface{} `yaml:"-"`           // Указатель на модель (не для YAML)
+	SheetName  string      `yaml:"sheet_name" json:"sheet_name"`
+	SignalType string      `yaml:"signal_type" json:"signal_type"` // "DI", "AI", "DQ", "AQ"
+	Model      interface{} `yaml:"-" json:"-"`                     // Указатель на модель (не для YAML)
 }
 
 type VarsConfig struct {
-	In  map[string]string `yaml:"in"`
-	Out map[string]string `yaml:"out"`
+	In  map[string]string `yaml:"in" json:"in"`
+	Out map[string]string `yaml:"out" json:"out"`
 }
 
 type OMXConfig struct {
-	Template   string            `yaml:"template"`
-	Attributes map[string]string `yaml:"attributes"`
+	Template   string            `yaml:"template" json:"template"`
+	Attributes map[string]string `yaml:"attributes" json:"attributes"`
 }
 
 type FBConfig struct {
-	Template string            `yaml:"st_template"`
-	In       map[string]string `yaml:"in"`
-	Out      map[string]string `yaml:"out"`
-	OMX      OMXConfig         `yaml:"omx"`
-	OPC      OPCConfig         `yaml:"opc"`
+	Template string            `yaml:"st_template" json:"st_template"`
+	In       map[string]string `yaml:"in" json:"in"`
+	Out      map[string]string `yaml:"out" json:"out"`
+	OMX      OMXConfig         `yaml:"omx" json:"omx"`
+	OPC      OPCConfig         `yaml:"opc" json:"opc"`
+	// Params - параметры экземпляра ФБ для секции инициализации:
+	// имя параметра -> поле сигнала (RangeMax, start.TON) или шаблон
+	Params map[string]string `yaml:"params,omitempty" json:"params,omitempty"`
+	// TagRules - правила разбора тэга сигнала на тэг ФБ и атрибут.
+	// Если не заданы, тэг ФБ - все до последнего '_', атрибут - после
+	TagRules []TagRuleConfig `yaml:"tag_rules,omitempty" json:"tag_rules,omitempty"`
+	// Required - обязательные входы/выходы ФБ (ключи In/Out), остальные необязательные
+	Required []string `yaml:"required,omitempty" json:"required,omitempty"`
+	// Defaults - значения для неподключенных входов/выходов ФБ
+	Defaults map[string]string `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+}
+
+// TagRuleConfig задает правило разбора тэга: регулярное выражение с группами
+// (?P<fb>...) и (?P<attr>...) либо шаблон вида "{fb}_{attr}"
+type TagRuleConfig struct {
+	Pattern  string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+// CompileTagRules компилирует правила разбора тэгов ФБ
+func (c FBConfig) CompileTagRules() ([]*models.FBTagRule, error) {
+	var rules []*models.FBTagRule
+	for _, rc := range c.TagRules {
+		rule, err := models.CompileFBTagRule(rc.Pattern, rc.Template)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ExportFormat - шаблоны файла выгрузки: заголовок и окончание выполняются
+// один раз со списком записей, строка - для каждой записи
+type ExportFormat struct {
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	Row    string `yaml:"row" json:"row"`
+	Footer string `yaml:"footer,omitempty" json:"footer,omitempty"`
+}
+
+// AlarmConfig - настройки выгрузки аварийных сообщений для SCADA
+type AlarmConfig struct {
+	// Formats - шаблоны выгрузки по имени формата (csv, xml)
+	Formats map[string]ExportFormat `yaml:"formats" json:"formats"`
+	// Priorities - приоритет по категории DI или виду уставки AI (LL, L, H, HH)
+	Priorities      map[string]int `yaml:"priorities,omitempty" json:"priorities,omitempty"`
+	DefaultPriority int            `yaml:"default_priority,omitempty" json:"default_priority,omitempty"`
+	// Messages - текст сообщения по категории или виду уставки
+	Messages map[string]string `yaml:"messages,omitempty" json:"messages,omitempty"`
+}
+
+// Settings возвращает правила формирования аварийных сообщений
+func (c AlarmConfig) Settings() models.AlarmSettings {
+	return models.AlarmSettings{
+		Priorities:      c.Priorities,
+		DefaultPriority: c.DefaultPriority,
+		Messages:        c.Messages,
+	}
+}
+
+// ArchiveConfig - настройки выгрузки конфигурации архива (historian)
+type ArchiveConfig struct {
+	Format ExportFormat `yaml:"format" json:"format"`
+	// Extension - расширение файла выгрузки, по умолчанию csv
+	Extension string `yaml:"extension,omitempty" json:"extension,omitempty"`
+	// Rules - правила отбора, первое подходящее применяется.
+	// Если не заданы, архивируются все AI с зоной нечувствительности 1%
+	Rules []ArchiveRuleConfig `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ArchiveRuleConfig - правило отбора тэгов в архив
+type ArchiveRuleConfig struct {
+	SignalType string   `yaml:"signal_type,omitempty" json:"signal_type,omitempty"`
+	CdsType    string   `yaml:"cds_type,omitempty" json:"cds_type,omitempty"`
+	Category   string   `yaml:"category,omitempty" json:"category,omitempty"`
+	Items      []string `yaml:"items,omitempty" json:"items,omitempty"`       // Пути внутри составного ФБ
+	Deadband   float64  `yaml:"deadband,omitempty" json:"deadband,omitempty"` // % диапазона
+	SampleRate string   `yaml:"sample_rate,omitempty" json:"sample_rate,omitempty"`
+	Exclude    bool     `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+}
+
+// ArchiveRules возвращает правила отбора тэгов в архив
+func (c ArchiveConfig) ArchiveRules() []models.ArchiveRule {
+	var rules []models.ArchiveRule
+	for _, r := range c.Rules {
+		rules = append(rules, models.ArchiveRule{
+			SignalType: r.SignalType,
+			CdsType:    r.CdsType,
+			Category:   r.Category,
+			Items:      r.Items,
+			Deadband:   r.Deadband,
+			SampleRate: r.SampleRate,
+			Exclude:    r.Exclude,
+		})
+	}
+	return rules
+}
+
+// ModbusConfig - настройки карты регистров Modbus
+type ModbusConfig struct {
+	// RegisterTypes - тип регистра по типу сигнала (coil, discrete, input, holding),
+	// если адрес задан без префикса. Для типа сигнала без значения адрес без префикса
+	// разбирается в нотации Modicon (40001 - holding 0)
+	RegisterTypes map[string]string `yaml:"register_types,omitempty" json:"register_types,omitempty"`
+	// DataTypes - тип данных регистра по типу сигнала (int16, uint16, int32, float32...)
+	DataTypes map[string]string `yaml:"data_types,omitempty" json:"data_types,omitempty"`
+	// RawMin, RawMax - диапазон кода целочисленных регистров для масштабирования
+	RawMin *float64     `yaml:"raw_min,omitempty" json:"raw_min,omitempty"`
+	RawMax *float64     `yaml:"raw_max,omitempty" json:"raw_max,omitempty"`
+	Format ExportFormat `yaml:"format" json:"format"` // Шаблон выгрузки CSV
+}
+
+// Settings возвращает правила построения карты Modbus
+func (c ModbusConfig) Settings() models.ModbusSettings {
+	return models.ModbusSettings{
+		RegisterTypes: c.RegisterTypes,
+		DataTypes:     c.DataTypes,
+		RawMin:        c.RawMin,
+		RawMax:        c.RawMax,
+	}
+}
+
+// NodeMatchingConfig - настройки сопоставления узлов сигналов с листом узлов
+type NodeMatchingConfig struct {
+	// Strategy - способ нечеткого сопоставления: pg_trgm (по умолчанию), exact, normalized, token, trigram
+	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+	// MinScore - сходство, ниже которого узел не выбирается и сигнал остается без узла
+	MinScore float64 `yaml:"min_score,omitempty" json:"min_score,omitempty"`
+	// ReviewBelow - сходство, ниже которого нечеткое совпадение попадает в очередь проверки
+	ReviewBelow float64 `yaml:"review_below,omitempty" json:"review_below,omitempty"`
+	// ReportSheet - лист отчета о сопоставлении, по умолчанию NodeMatches
+	ReportSheet string `yaml:"report_sheet,omitempty" json:"report_sheet,omitempty"`
+}
+
+// Matcher возвращает сопоставитель узлов; nil - сопоставление средствами pg_trgm в БД
+func (c NodeMatchingConfig) Matcher() (models.NodeMatcher, error) {
+	if c.Strategy == "" || c.Strategy == models.MatchPgTrgm {
+		return nil, nil
+	}
+	return models.NewNodeMatcher(c.Strategy)
+}
+
+// ReviewThreshold возвращает порог очереди проверки (по умолчанию 0.6)
+func (c NodeMatchingConfig) ReviewThreshold() float64 {
+	if c.ReviewBelow <= 0 {
+		return 0.6
+	}
+	return c.ReviewBelow
+}
+
+// ModuleConfig - тип модуля ввода/вывода в каталоге
+type ModuleConfig struct {
+	Code       string `yaml:"code" json:"code"`
+	Match      string `yaml:"match,omitempty" json:"match,omitempty"` // Регулярное выражение для значения module сигнала
+	Channels   int    `yaml:"channels" json:"channels"`
+	SignalType string `yaml:"signal_type,omitempty" json:"signal_type,omitempty"`
+	Addressing string `yaml:"addressing,omitempty" json:"addressing,omitempty"`
+}
+
+// ModuleSpecs возвращает каталог модулей из конфига
+func (c *AppConfig) ModuleSpecs() []models.ModuleSpec {
+	var specs []models.ModuleSpec
+	for _, m := range c.Modules {
+		specs = append(specs, models.ModuleSpec{
+			Code:       m.Code,
+			Match:      m.Match,
+			Channels:   m.Channels,
+			SignalType: m.SignalType,
+			Addressing: m.Addressing,
+		})
+	}
+	return specs
 }
+
 type OPCConfig struct {
-	Items []string `yaml:"items"`
+	Items []string `yaml:"items" json:"items"`
 }
 
 type OPCItemTemplate struct {
-	BasePath   string `yaml:"base_path"`
-	NodePrefix string `yaml:"node_prefix"`
-	Namespace  string `yaml:"namespace"`
-	NodeIdType string `yaml:"nodeIdType"`
-	Binding    string `yaml:"binding"`
+	BasePath   string `yaml:"base_path" json:"base_path"`
+	NodePrefix string `yaml:"node_prefix" json:"node_prefix"`
+	Namespace  string `yaml:"namespace" json:"namespace"`
+	NodeIdType string `yaml:"nodeIdType" json:"nodeIdType"`
+	Binding    string `yaml:"binding" json:"binding"`
 }
 
 type AppConfig struct {
-	DB              *DatabaseConfig     `yaml:"db"`
-	SpreadsheetID   string              `yaml:"spreadsheet_id"`
-	Update          bool                `yaml:"update"`
-	Sheets          []SheetConfig       `yaml:"sheets"`
-	FunctionBlocks  map[string]FBConfig `yaml:"function_blocks"`
-	Systems         []string            `yaml:"systems"`
-	NodeSheet       string              `yaml:"nodesheet"`
-	DefaultOPCItem  OPCItemTemplate     `yaml:"default_opc"`
-	ProductSheet    string              `yaml:"productsheet"`
-	AddressTemplate map[string]string   `yaml:"address_template"`
+	DB            *DatabaseConfig `yaml:"db" json:"db"`
+	SpreadsheetID string          `yaml:"spreadsheet_id" json:"spreadsheet_id"`
+	// CredentialsFile - файл ключа сервисного аккаунта Google, по умолчанию credentials.json
+	CredentialsFile string `yaml:"credentials_file,omitempty" json:"credentials_file,omitempty"`
+	// Credentials - содержимое ключа, обычно ссылка ${env:NAME} или ${file:PATH}; приоритетнее файла
+	Credentials     string              `yaml:"credentials,omitempty" json:"credentials,omitempty" secret:"true"`
+	Update          bool                `yaml:"update" json:"update"`
+	Sheets          []SheetConfig       `yaml:"sheets" json:"-"` // Задаются в LoadConfig
+	FunctionBlocks  map[string]FBConfig `yaml:"function_blocks" json:"function_blocks"`
+	Systems         []string            `yaml:"systems" json:"systems"`
+	NodeSheet       string              `yaml:"nodesheet" json:"nodesheet"`
+	DefaultOPCItem  OPCItemTemplate     `yaml:"default_opc" json:"default_opc"`
+	ProductSheet    string              `yaml:"productsheet" json:"productsheet"`
+	InterlockSheet  string              `yaml:"interlocksheet,omitempty" json:"interlocksheet,omitempty"`
+	HardwareSheet   string              `yaml:"hardwaresheet,omitempty" json:"hardwaresheet,omitempty"` // Лист отчета по оборудованию, по умолчанию Hardware
+	ModuleSheet     string              `yaml:"modulesheet,omitempty" json:"modulesheet,omitempty"`     // Лист каталога модулей (дополняет modules)
+	Modules         []ModuleConfig      `yaml:"modules,omitempty" json:"modules,omitempty"`
+	Alarms          AlarmConfig         `yaml:"alarms,omitempty" json:"alarms,omitempty"`
+	Archive         ArchiveConfig       `yaml:"archive,omitempty" json:"archive,omitempty"`
+	Modbus          ModbusConfig        `yaml:"modbus,omitempty" json:"modbus,omitempty"`
+	NodeMatching    NodeMatchingConfig  `yaml:"node_matching,omitempty" json:"node_matching,omitempty"`
+	AddressTemplate map[string]string   `yaml:"address_template" json:"address_template"`
+
+	// fileValues - значения полей из файла, замененные переменными окружения или
+	// ссылками на секреты; в файл сохраняются они, а не подставленные значения
+	fileValues map[string]interface{}
 }
 
 func CreateDefaultConfigIfNotExist(filename string) error {
@@ -82,16 +298,30 @@ func CreateDefaultConfigIfNotExist(filename string) error {
 	return nil
 }
 
-// LoadConfig загружает конфиг из файла
+// LoadConfig загружает конфиг из файла, делает его текущим (Get) и запоминает Path
 func LoadConfig(path string) *AppConfig {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatalf("Failed to read config file: %v", err)
 	}
+	cfg, err := Parse(data)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	Path = path
+	rememberFile(data)
+	Set(cfg)
+	return cfg
+}
 
+// Parse разбирает YAML конфига и подставляет переменные окружения и секреты
+func Parse(data []byte) (*AppConfig, error) {
 	var cfg AppConfig
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to apply config overrides: %w", err)
 	}
 
 	// Инициализируем модели для листов
@@ -118,5 +348,5 @@ func LoadConfig(path string) *AppConfig {
 		},
 	}
 
-	return &cfg
+	return &cfg, nil
 }
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix - префикс переменных окружения, переопределяющих поля конфига:
+// ASTRAGEN_DB_PASSWORD задает db.password, ASTRAGEN_NODE_MATCHING_MIN_SCORE - node_matching.min_score.
+// Списки строк задаются через запятую, словари и списки структур не переопределяются
+const EnvPrefix = "ASTRAGEN_"
+
+// Redacted - значение заданного секретного поля в GetConfig
+const Redacted = "********"
+
+// configField - скалярное поле конфига с путем из yaml-имен
+type configField struct {
+	path   string
+	value  reflect.Value
+	secret bool
+	// allocated - вложенная структура создана вместо nil-указателя, потому что
+	// переопределено ее поле; value - указатель на нее
+	allocated bool
+}
+
+// walkFields обходит скалярные поля, списки строк и вложенные структуры конфига
+func walkFields(v reflect.Value, prefix string, fn func(f configField) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fv := v.Field(i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct:
+			if err := walkStruct(fv, path, fn); err != nil {
+				return err
+			}
+		case isScalar(ft.Kind()) || ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.String:
+			if err := fn(configField{path: path, value: fv, secret: sf.Tag.Get("secret") == "true"}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkStruct обходит вложенную структуру; nil-указатель заменяется новой структурой,
+// только если переопределено хотя бы одно ее поле
+func walkStruct(fv reflect.Value, path string, fn func(f configField) error) error {
+	if fv.Kind() != reflect.Ptr {
+		return walkFields(fv, path, fn)
+	}
+	if !fv.IsNil() {
+		return walkFields(fv.Elem(), path, fn)
+	}
+	nested := reflect.New(fv.Type().Elem())
+	changed := false
+	err := walkFields(nested.Elem(), path, func(f configField) error {
+		before := f.value.Interface()
+		if err := fn(f); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(before, f.value.Interface()) {
+			changed = true
+		}
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+	fv.Set(nested)
+	return fn(configField{path: path, value: fv, allocated: true})
+}
+
+func isScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// EnvName возвращает имя переменной окружения для поля конфига
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// applyEnv подставляет переменные окружения и ссылки на секреты в поля конфига
+// и запоминает исходные значения замененных полей
+func applyEnv(cfg *AppConfig) error {
+	cfg.fileValues = map[string]interface{}{}
+	return walkFields(reflect.ValueOf(cfg).Elem(), "", func(f configField) error {
+		if f.allocated {
+			cfg.fileValues[f.path] = reflect.Zero(f.value.Type()).Interface()
+			return nil
+		}
+		original := f.value.Interface()
+		if raw, ok := os.LookupEnv(EnvName(f.path)); ok {
+			if err := setField(f.value, raw); err != nil {
+				return fmt.Errorf("invalid %s: %w", EnvName(f.path), err)
+			}
+		}
+		if f.value.Kind() == reflect.String {
+			resolved, err := ResolveSecret(f.value.String())
+			if err != nil {
+				return fmt.Errorf("failed to resolve %s: %w", f.path, err)
+			}
+			f.value.SetString(resolved)
+		}
+		if !reflect.DeepEqual(original, f.value.Interface()) {
+			cfg.fileValues[f.path] = original
+		}
+		return nil
+	})
+}
+
+// setField разбирает значение переменной окружения по типу поля
+func setField(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setField(ptr.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
+
+// ResolveSecret подставляет значение ссылки на секрет: ${env:NAME} - переменная
+// окружения, ${file:PATH} - содержимое файла. Остальные значения возвращаются как есть
+func ResolveSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+		return value, nil
+	}
+	ref := value[2 : len(value)-1]
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return resolved, nil
+	case strings.HasPrefix(ref, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// SecretPaths возвращает пути секретных полей конфига (db.password, credentials)
+func SecretPaths() []string {
+	var paths []string
+	walkFields(reflect.ValueOf(&AppConfig{DB: &DatabaseConfig{}}).Elem(), "", func(f configField) error {
+		if f.secret {
+			paths = append(paths, f.path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// Redact скрывает значение секретного поля
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// Overridden возвращает поля, значения которых взяты не из файла конфига
+func (c *AppConfig) Overridden() []string {
+	paths := make([]string, 0, len(c.fileValues))
+	for path := range c.fileValues {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// marshalFile сериализует конфиг для записи в файл: вместо значений из переменных
+// окружения и секретов пишутся исходные значения файла
+func marshalFile(cfg *AppConfig) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if len(cfg.fileValues) == 0 {
+		return data, nil
+	}
+	var saved AppConfig
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	// Вложенные поля восстанавливаются раньше структуры, которая их содержит
+	paths := cfg.Overridden()
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+	for _, p := range paths {
+		if field := lookupField(reflect.ValueOf(&saved).Elem(), strings.Split(p, ".")); field.IsValid() {
+			field.Set(reflect.ValueOf(cfg.fileValues[p]))
+		}
+	}
+	if data, err = yaml.Marshal(&saved); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// lookupField находит поле по yaml-именам; пустой результат - поля нет
+func lookupField(v reflect.Value, path []string) reflect.Value {
+	for i, name := range path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		found := reflect.Value{}
+		for j := 0; j < v.NumField(); j++ {
+			if strings.Split(v.Type().Field(j).Tag.Get("yaml"), ",")[0] == name {
+				found = v.Field(j)
+				break
+			}
+		}
+		if !found.IsValid() || i == len(path)-1 {
+			return found
+		}
+		v = found
+	}
+	return reflect.Value{}
+}
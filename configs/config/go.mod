@@ -2,8 +2,6 @@ module github.com/mejzh77/astragen/configs/config
 
 go 1.24.4
 
-//replace github.com/mejzh77/astragen/pkg/models => ../../pkg/models
-
 require (
 	github.com/mejzh77/astragen/pkg/models v0.0.0-20250729085150-9d43c23bb774
 	gopkg.in/yaml.v3 v3.0.1
@@ -40,3 +38,5 @@ require (
 	google.golang.org/protobuf v1.36.6 // indirect
 	gorm.io/gorm v1.30.1 // indirect
 )
+
+replace github.com/mejzh77/astragen/pkg/models => ../../pkg/models
@@ -0,0 +1,372 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// FieldError - ошибка проверки поля конфига; Path - путь из json-имен (db.driver, modules[0].channels)
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// enums - допустимые значения полей; * - значение словаря, [] - элемент списка
+var enums = map[string][]string{
+	"db.driver":                   {"postgres", "sqlite"},
+	"db.ssl_mode":                 {"disable", "allow", "prefer", "require", "verify-ca", "verify-full"},
+	"node_matching.strategy":      {models.MatchPgTrgm, models.MatchExact, models.MatchNormalized, models.MatchToken, models.MatchTrigram},
+	"modbus.register_types.*":     {"coil", "discrete", "input", "holding"},
+	"modules[].signal_type":       {"DI", "AI", "DQ", "AQ"},
+	"archive.rules[].signal_type": {"DI", "AI", "DQ", "AQ"},
+}
+
+// bounds - минимальное и максимальное значения числовых полей
+var bounds = map[string][2]float64{
+	"node_matching.min_score":    {0, 1},
+	"node_matching.review_below": {0, 1},
+	"modules[].channels":         {1, 1024},
+	"archive.rules[].deadband":   {0, 100},
+}
+
+// Schema возвращает JSON Schema конфига для редактора и проверки на клиенте
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(AppConfig{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "astragen config"
+	return schema
+}
+
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+	schema := map[string]interface{}{}
+	switch t.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), path+"[]")
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), path+".*")
+	case reflect.Struct:
+		schema["type"] = "object"
+		schema["additionalProperties"] = false
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name := strings.Split(sf.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			property := typeSchema(sf.Type, fieldPath)
+			if sf.Tag.Get("secret") == "true" {
+				property["readOnly"] = true
+				property["description"] = fmt.Sprintf("Секрет: задается переменной %s или ссылкой ${env:NAME}, ${file:PATH}", EnvName(fieldPath))
+			}
+			properties[name] = property
+		}
+		schema["properties"] = properties
+	}
+	if values, ok := enums[path]; ok {
+		schema["enum"] = values
+	}
+	if b, ok := bounds[path]; ok {
+		schema["minimum"], schema["maximum"] = b[0], b[1]
+	}
+	if nullable {
+		schema["type"] = []interface{}{schema["type"], "null"}
+	}
+	return schema
+}
+
+// Validate проверяет значения конфига: перечисления, диапазоны, шаблоны, регулярные выражения
+func (c *AppConfig) Validate() []FieldError {
+	v := &validator{}
+	if c.DB == nil {
+		v.add("db", "database settings are required")
+	} else {
+		v.enum("db.driver", c.DB.Driver)
+		v.enum("db.ssl_mode", c.DB.SSLMode)
+		if c.DB.DriverName() == "postgres" {
+			v.required("db.host", c.DB.Host)
+			v.required("db.name", c.DB.Name)
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, system := range c.Systems {
+		path := fmt.Sprintf("systems[%d]", i)
+		v.required(path, system)
+		if seen[system] {
+			v.add(path, fmt.Sprintf("duplicate system %q", system))
+		}
+		seen[system] = true
+	}
+	for _, key := range sortedKeys(c.AddressTemplate) {
+		v.template("address_template."+key, c.AddressTemplate[key])
+	}
+
+	for _, name := range sortedKeys(c.FunctionBlocks) {
+		fb := c.FunctionBlocks[name]
+		path := "function_blocks." + name
+		v.template(path+".st_template", fb.Template)
+		v.template(path+".omx.template", fb.OMX.Template)
+		for _, pin := range fb.Required {
+			_, in := fb.In[pin]
+			_, out := fb.Out[pin]
+			if !in && !out {
+				v.add(path+".required", fmt.Sprintf("%q is not an input or output", pin))
+			}
+		}
+		for i, rule := range fb.TagRules {
+			if _, err := models.CompileFBTagRule(rule.Pattern, rule.Template); err != nil {
+				v.add(fmt.Sprintf("%s.tag_rules[%d]", path, i), err.Error())
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Alarms.Formats) {
+		v.format("alarms.formats."+name, c.Alarms.Formats[name], true)
+	}
+	v.format("archive.format", c.Archive.Format, false)
+	for i, rule := range c.Archive.Rules {
+		path := fmt.Sprintf("archive.rules[%d]", i)
+		v.enum("archive.rules[].signal_type", rule.SignalType, path+".signal_type")
+		v.bounds("archive.rules[].deadband", rule.Deadband, path+".deadband")
+	}
+
+	v.format("modbus.format", c.Modbus.Format, false)
+	for _, key := range sortedKeys(c.Modbus.RegisterTypes) {
+		v.enum("modbus.register_types.*", c.Modbus.RegisterTypes[key], "modbus.register_types."+key)
+	}
+	if c.Modbus.RawMin != nil && c.Modbus.RawMax != nil && *c.Modbus.RawMin >= *c.Modbus.RawMax {
+		v.add("modbus.raw_max", "must be greater than raw_min")
+	}
+
+	if c.NodeMatching.Strategy != "" {
+		v.enum("node_matching.strategy", c.NodeMatching.Strategy)
+	}
+	v.bounds("node_matching.min_score", c.NodeMatching.MinScore)
+	v.bounds("node_matching.review_below", c.NodeMatching.ReviewBelow)
+
+	for i, m := range c.Modules {
+		path := fmt.Sprintf("modules[%d]", i)
+		v.required(path+".code", m.Code)
+		v.bounds("modules[].channels", float64(m.Channels), path+".channels")
+		v.enum("modules[].signal_type", m.SignalType, path+".signal_type")
+		if m.Match != "" {
+			if _, err := regexp.Compile(m.Match); err != nil {
+				v.add(path+".match", err.Error())
+			}
+		}
+	}
+	return v.errors
+}
+
+// validator собирает ошибки проверки полей
+type validator struct {
+	errors []FieldError
+}
+
+func (v *validator) add(path, message string) {
+	v.errors = append(v.errors, FieldError{Path: path, Message: message})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+// enum проверяет значение по enums[key]; at - путь поля, если отличается от key
+func (v *validator) enum(key, value string, at ...string) {
+	if value == "" {
+		return
+	}
+	for _, allowed := range enums[key] {
+		if value == allowed {
+			return
+		}
+	}
+	v.add(fieldPath(key, at), fmt.Sprintf("must be one of %s", strings.Join(enums[key], ", ")))
+}
+
+func (v *validator) bounds(key string, value float64, at ...string) {
+	b := bounds[key]
+	if value < b[0] || value > b[1] {
+		v.add(fieldPath(key, at), fmt.Sprintf("must be between %g and %g", b[0], b[1]))
+	}
+}
+
+func (v *validator) template(path, text string) {
+	if text == "" {
+		return
+	}
+	if _, err := template.New(path).Funcs(models.TemplateFuncs()).Parse(text); err != nil {
+		v.add(path, err.Error())
+	}
+}
+
+// format проверяет шаблоны выгрузки; rowRequired - строка обязательна
+func (v *validator) format(path string, f ExportFormat, rowRequired bool) {
+	if rowRequired {
+		v.required(path+".row", f.Row)
+	}
+	v.template(path+".header", f.Header)
+	v.template(path+".row", f.Row)
+	v.template(path+".footer", f.Footer)
+}
+
+func fieldPath(key string, at []string) string {
+	if len(at) > 0 {
+		return at[0]
+	}
+	return key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Clone возвращает независимую копию конфига
+func (c *AppConfig) Clone() (*AppConfig, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var clone AppConfig
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	clone.Inherit(c)
+	return &clone, nil
+}
+
+// Inherit переносит из from поля, которые не передаются в JSON: модели листов и
+// исходные значения полей, замененных переменными окружения
+func (c *AppConfig) Inherit(from *AppConfig) {
+	c.Sheets = from.Sheets
+	c.fileValues = from.fileValues
+}
+
+// Redacted возвращает копию конфига со скрытыми секретами для отдачи клиенту
+func (c *AppConfig) Redacted() (*AppConfig, error) {
+	clone, err := c.Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range SecretPaths() {
+		if field := lookupField(reflect.ValueOf(clone).Elem(), strings.Split(path, ".")); field.IsValid() {
+			field.SetString(Redact(field.String()))
+		}
+	}
+	return clone, nil
+}
+
+// MarshalRedacted возвращает YAML конфига в том виде, в каком он записывается в файл,
+// но со скрытыми секретами; ссылки ${env:NAME}, ${file:PATH} сохраняются
+func (c *AppConfig) MarshalRedacted() ([]byte, error) {
+	redacted, err := c.Redacted()
+	if err != nil {
+		return nil, err
+	}
+	redacted.fileValues = make(map[string]interface{}, len(c.fileValues))
+	for path, value := range c.fileValues {
+		if ref, ok := value.(string); ok && isSecretPath(path) && !strings.HasPrefix(ref, "${") {
+			continue
+		}
+		redacted.fileValues[path] = value
+	}
+	return marshalFile(redacted)
+}
+
+// KeepProtected переносит в конфиг из current секреты и поля, заданные переменными
+// окружения: их нельзя менять через API. Пустой или скрытый секрет означает
+// "без изменений", другое значение - ошибка. Значение секрета для файла тоже берется
+// из current: в конфиге из истории версий оно скрыто
+func (c *AppConfig) KeepProtected(current *AppConfig) []FieldError {
+	var errs []FieldError
+	newValue := reflect.ValueOf(c).Elem()
+	oldValue := reflect.ValueOf(current).Elem()
+	if c.fileValues == nil {
+		c.fileValues = map[string]interface{}{}
+	}
+	for _, path := range SecretPaths() {
+		if original, ok := current.fileValues[path]; ok {
+			c.fileValues[path] = original
+		} else {
+			delete(c.fileValues, path)
+		}
+
+		field := lookupField(newValue, strings.Split(path, "."))
+		old := lookupField(oldValue, strings.Split(path, "."))
+		if !field.IsValid() {
+			continue
+		}
+		value := field.String()
+		if old.IsValid() && value != "" && value != Redacted && value != old.String() {
+			errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(
+				"is a secret and cannot be changed via API, set %s or a ${env:NAME} reference in the config file", EnvName(path))})
+			continue
+		}
+		if old.IsValid() {
+			field.SetString(old.String())
+		} else {
+			field.SetString("")
+		}
+	}
+	for _, path := range current.Overridden() {
+		field := lookupField(newValue, strings.Split(path, "."))
+		old := lookupField(oldValue, strings.Split(path, "."))
+		if !field.IsValid() || !old.IsValid() || reflect.DeepEqual(field.Interface(), old.Interface()) {
+			continue
+		}
+		if isSecretPath(path) {
+			continue
+		}
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf("is set by environment variable %s", EnvName(path))})
+	}
+	return errs
+}
+
+func isSecretPath(path string) bool {
+	for _, secret := range SecretPaths() {
+		if secret == path {
+			return true
+		}
+	}
+	return false
+}
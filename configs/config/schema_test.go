@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRollbackKeepsEnvOverriddenSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("db:\n  driver: sqlite\n  password: file-secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvName("db.password"), "env-secret")
+	current := LoadConfig(path)
+
+	// Версия в истории хранится со скрытыми секретами
+	content, err := current.MarshalRedacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), Redacted) {
+		t.Fatalf("version content does not hide db.password:\n%s", content)
+	}
+
+	next, err := Update(func(current *AppConfig) (*AppConfig, error) {
+		next, err := Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		if errs := next.KeepProtected(current); len(errs) > 0 {
+			t.Fatalf("KeepProtected: %+v", errs)
+		}
+		return next, nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if next.DB.Password != "env-secret" {
+		t.Errorf("db.password after rollback = %q, want env-secret", next.DB.Password)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), Redacted) || !strings.Contains(string(data), "file-secret") {
+		t.Errorf("config file after rollback lost db.password from the file:\n%s", data)
+	}
+}
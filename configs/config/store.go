@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// current - действующий конфиг. Значение не изменяется на месте: изменения
+// собираются в новом экземпляре и заменяют его целиком (Set, Update, Reload)
+var current atomic.Pointer[AppConfig]
+
+var (
+	// writeMu упорядочивает Update и Reload
+	writeMu stdsync.Mutex
+	// fileHash - хэш содержимого Path, известного приложению (загружено или записано)
+	fileHash [sha256.Size]byte
+)
+
+// ErrReloadSkipped - файл конфига не изменился с последней загрузки или записи
+var ErrReloadSkipped = errors.New("config file unchanged")
+
+// Get возвращает текущий конфиг; его нельзя изменять, он может быть заменен в любой момент.
+// Для последовательной работы с конфигом значение получают один раз и передают дальше
+func Get() *AppConfig {
+	return current.Load()
+}
+
+// Set делает cfg текущим конфигом
+func Set(cfg *AppConfig) {
+	current.Store(cfg)
+}
+
+// Update строит новый конфиг из текущего, записывает его в Path и делает текущим.
+// Параллельно с Update не выполняется Reload, поэтому правки файла не теряются
+func Update(build func(current *AppConfig) (*AppConfig, error)) (*AppConfig, error) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	cur := Get()
+	if cur == nil {
+		return nil, fmt.Errorf("config not loaded")
+	}
+	next, err := build(cur)
+	if err != nil {
+		return nil, err
+	}
+	data, err := marshalFile(next)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(Path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	fileHash = sha256.Sum256(data)
+	Set(next)
+	return next, nil
+}
+
+// Reload перечитывает Path и делает его текущим, если содержимое изменилось и
+// прошло проверку Validate. Неверный конфиг не применяется и повторно не сообщается,
+// пока файл не изменится снова
+func Reload() (*AppConfig, error) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	data, err := os.ReadFile(Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	if hash == fileHash {
+		return nil, ErrReloadSkipped
+	}
+	fileHash = hash
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if fields := cfg.Validate(); len(fields) > 0 {
+		var buf bytes.Buffer
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteString("; ")
+			}
+			buf.WriteString(f.Error())
+		}
+		return nil, fmt.Errorf("invalid config: %s", buf.String())
+	}
+	Set(cfg)
+	return cfg, nil
+}
+
+// Watch проверяет Path каждые interval и перечитывает его при изменении (Reload) до
+// отмены ctx. onReload вызывается с предыдущим и новым конфигом или с ошибкой загрузки
+func Watch(ctx context.Context, interval time.Duration, onReload func(prev, next *AppConfig, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(Path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(Path)
+		if err != nil || info.ModTime().Equal(modTime) && info.Size() == size {
+			continue
+		}
+		modTime, size = info.ModTime(), info.Size()
+
+		prev := Get()
+		next, err := Reload()
+		if errors.Is(err, ErrReloadSkipped) {
+			continue
+		}
+		onReload(prev, next, err)
+	}
+}
+
+// rememberFile запоминает содержимое Path, загруженное при старте
+func rememberFile(data []byte) {
+	writeMu.Lock()
+	fileHash = sha256.Sum256(data)
+	writeMu.Unlock()
+}
@@ -21,10 +21,13 @@ require (
 	github.com/bytedance/sonic v1.14.0 // indirect
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
 	github.com/cloudwego/base64x v0.1.5 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/felixge/httpsnoop v1.0.4 // indirect
 	github.com/foolin/goview v0.3.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
 	github.com/gin-contrib/sse v1.1.0 // indirect
+	github.com/glebarez/go-sqlite v1.21.2 // indirect
+	github.com/glebarez/sqlite v1.11.0 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
@@ -52,6 +55,7 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.3.0 // indirect
 	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
@@ -72,18 +76,18 @@ require (
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	gorm.io/driver/postgres v1.6.0 // indirect
+	modernc.org/libc v1.22.5 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.5.0 // indirect
+	modernc.org/sqlite v1.23.1 // indirect
 )
 
-//replace github.com/mejzh77/astragen/internal/gsheets => ./internal/gsheets
-//
-//replace github.com/mejzh77/astragen/configs/config => ./configs/config
-//
-//replace github.com/mejzh77/astragen/pkg/models => ./pkg/models
-//
-//replace github.com/mejzh77/astragen/internal/repository => ./internal/repository
-//
-//replace github.com/mejzh77/astragen/internal/sync => ./internal/sync
-//
-//replace github.com/mejzh77/astragen/internal/database => ./internal/database
-//
-//replace github.com/mejzh77/astragen/internal/api => ./internal/api
+replace (
+	github.com/mejzh77/astragen/configs/config => ./configs/config
+	github.com/mejzh77/astragen/internal/api => ./internal/api
+	github.com/mejzh77/astragen/internal/database => ./internal/database
+	github.com/mejzh77/astragen/internal/gsheets => ./internal/gsheets
+	github.com/mejzh77/astragen/internal/repository => ./internal/repository
+	github.com/mejzh77/astragen/internal/sync => ./internal/sync
+	github.com/mejzh77/astragen/pkg/models => ./pkg/models
+)
@@ -2,25 +2,13 @@ module github.com/mejzh77/astragen/internal/api
 
 go 1.24.4
 
-//replace github.com/mejzh77/astragen/internal/api/handlers => ./handlers
-//
-//replace github.com/mejzh77/astragen/internal/repository => ../repository
-//
-//replace github.com/mejzh77/astragen/internal/gsheets => ../gsheets
-//
-//replace github.com/mejzh77/astragen/configs/config => ../../configs/config
-//
-//replace github.com/mejzh77/astragen/pkg/models => ../../pkg/models
-//
-//replace github.com/mejzh77/astragen/internal/sync => ../sync
-
 require (
 	github.com/foolin/goview v0.3.0
 	github.com/gin-gonic/gin v1.10.1
 	github.com/gorilla/websocket v1.5.3
 	github.com/mejzh77/astragen/configs/config v0.0.0-20250729085150-9d43c23bb774
 	github.com/mejzh77/astragen/internal/sync v0.0.0-20250729085150-9d43c23bb774
-
+	github.com/mejzh77/astragen/pkg/models v0.0.0-20250729085150-9d43c23bb774
 )
 
 require (
@@ -30,9 +18,12 @@ require (
 	github.com/bytedance/sonic v1.14.0 // indirect
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
 	github.com/cloudwego/base64x v0.1.5 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/felixge/httpsnoop v1.0.4 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
 	github.com/gin-contrib/sse v1.1.0 // indirect
+	github.com/glebarez/go-sqlite v1.21.2 // indirect
+	github.com/glebarez/sqlite v1.11.0 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
@@ -43,18 +34,23 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/googleapis/enterprise-certificate-proxy v0.3.6 // indirect
 	github.com/googleapis/gax-go/v2 v2.15.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/pgx/v5 v5.7.5 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mejzh77/astragen/internal/database v0.0.0-20250729085150-9d43c23bb774 // indirect
 	github.com/mejzh77/astragen/internal/gsheets v0.0.0-20250729085150-9d43c23bb774 // indirect
 	github.com/mejzh77/astragen/internal/repository v0.0.0-20250729085150-9d43c23bb774 // indirect
-	github.com/mejzh77/astragen/pkg/models v0.0.0-20250729085150-9d43c23bb774 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.3.0 // indirect
 	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
@@ -66,6 +62,7 @@ require (
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
 	google.golang.org/api v0.243.0 // indirect
@@ -73,5 +70,19 @@ require (
 	google.golang.org/grpc v1.74.2 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gorm.io/driver/postgres v1.6.0 // indirect
 	gorm.io/gorm v1.30.1 // indirect
+	modernc.org/libc v1.22.5 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.5.0 // indirect
+	modernc.org/sqlite v1.23.1 // indirect
+)
+
+replace (
+	github.com/mejzh77/astragen/configs/config => ../../configs/config
+	github.com/mejzh77/astragen/internal/database => ../database
+	github.com/mejzh77/astragen/internal/gsheets => ../gsheets
+	github.com/mejzh77/astragen/internal/repository => ../repository
+	github.com/mejzh77/astragen/internal/sync => ../sync
+	github.com/mejzh77/astragen/pkg/models => ../../pkg/models
 )
@@ -1,17 +1,23 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/foolin/goview"
 	"github.com/foolin/goview/supports/ginview"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/internal/sync"
+	"github.com/mejzh77/astragen/pkg/models"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 	stdsync "sync"
+	"time"
 )
 
 var upgrader = websocket.Upgrader{
@@ -112,14 +118,50 @@ func (s *WebService) RegisterRoutes() {
 	s.router.POST("/api/sync", s.SyncData)
 	s.router.GET("/api/tree-data", s.GetTreeData)
 	s.router.GET("/api/details", s.getItemDetails)
+	s.router.GET("/api/search", s.Search)
+	s.router.GET("/api/signals", s.ListSignals)
+	s.router.GET("/api/signals/:id", s.GetSignal)
+	s.router.POST("/api/signals", s.CreateSignal)
+	s.router.PUT("/api/signals/:id", s.UpdateSignal)
+	s.router.DELETE("/api/signals/:id", s.DeleteSignal)
+	s.router.GET("/api/function-blocks", s.ListFunctionBlocks)
+	s.router.GET("/api/function-blocks/:id", s.GetFunctionBlock)
+	s.router.PUT("/api/function-blocks/:id", s.UpdateFunctionBlock)
+	s.router.POST("/api/function-blocks/:id/variables", s.AddFBVariable)
+	s.router.DELETE("/api/function-blocks/:id/variables/:varId", s.RemoveFBVariable)
+	s.router.POST("/api/function-blocks/:id/regenerate", s.RegenerateFunctionBlock)
 	s.router.GET("/api/config", s.GetConfig)
-	s.router.POST("/api/config", s.UpdateConfig)
+	s.router.GET("/api/config/schema", s.GetConfigSchema)
+	s.router.PUT("/api/config", s.ReplaceConfig)
+	s.router.POST("/api/config", s.ReplaceConfig)
+	s.router.PATCH("/api/config", s.PatchConfig)
+	s.router.GET("/api/config/versions", s.ListConfigVersions)
+	s.router.GET("/api/config/versions/:version", s.GetConfigVersion)
+	s.router.POST("/api/config/rollback/:version", s.RollbackConfig)
 	s.router.GET("/config", s.ConfigPage)
 	s.router.GET("/ws", s.handleWebSocket)
 	s.router.GET("/generate", s.GenerateImportPage)
 	s.router.POST("/api/generate-import", s.GenerateImportFile)
 	s.router.POST("/api/regenerate-import-files", s.RegenerateAllImportFiles)
 	s.router.GET("/api/nodes", s.GetNodesBySystem)
+	s.router.GET("/api/fb-tag-report", s.GetFBTagReport)
+	s.router.GET("/api/fb-completeness", s.GetFBCompleteness)
+	s.router.GET("/api/graph", s.GetFBGraph)
+	s.router.GET("/api/export/alarms", s.ExportAlarms)
+	s.router.GET("/api/export/archive", s.ExportArchive)
+	s.router.GET("/api/export/modbus", s.ExportModbus)
+	s.router.GET("/api/modbus", s.GetModbusMap)
+	s.router.GET("/hardware", s.HardwarePage)
+	s.router.GET("/api/hardware", s.GetHardwareReport)
+	s.router.POST("/api/hardware/export", s.ExportHardware)
+	s.router.GET("/api/modules", s.GetModuleReport)
+	s.router.GET("/nodes", s.NodesPage)
+	s.router.GET("/api/node-overrides", s.ListNodeOverrides)
+	s.router.POST("/api/node-overrides", s.SaveNodeOverride)
+	s.router.DELETE("/api/node-overrides/:id", s.DeleteNodeOverride)
+	s.router.GET("/api/node-review", s.GetNodeReview)
+	s.router.GET("/api/node-matches", s.GetNodeMatchReport)
+	s.router.POST("/api/node-matches/export", s.ExportNodeMatchReport)
 
 }
 
@@ -175,7 +217,7 @@ func (s *WebService) GenerateImportPage(c *gin.Context) {
 
 func getAvailableFBTypes() []string {
 	var types []string
-	for k := range config.Cfg.FunctionBlocks {
+	for k := range config.Get().FunctionBlocks {
 		types = append(types, k)
 	}
 	return types
@@ -193,91 +235,238 @@ func (s *WebService) GenerateImportFile(c *gin.Context) {
 		return
 	}
 
-	fbs, err := s.syncService.GetFilteredFunctionBlocks(request.System, request.CdsType, request.Node)
+	file, err := s.syncService.GenerateImportFile(request.System, request.CdsType, request.Node, request.FileType)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if sync.IsInterlocksFile(request.FileType) {
+		c.JSON(http.StatusOK, gin.H{"content": file.Content, "count": file.Count})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"content":    file.Content,
+		"count":      file.Count,
+		"incomplete": file.Incomplete,
+		"cycle":      file.Cycle,
+	})
+}
+
+func (s *WebService) GetFBGraph(c *gin.Context) {
+	graph, err := s.syncService.GetFBGraph(c.Query("system"), c.Query("cdsType"), c.Query("node"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var content strings.Builder
-	for _, fb := range fbs {
-		switch request.FileType {
-		case "STDecl":
-			if fb.Call != "" {
-				content.WriteString(fb.Declaration + "\n\n")
-			}
-		case "ST":
-			if fb.Call != "" {
-				content.WriteString(fb.Call + "\n\n")
-			}
-		case "OMX":
-			if fb.OMX != "" {
-				content.WriteString(fb.OMX + "\n\n")
-			}
-		case "OPC":
-			if fb.OPC != "" {
-				content.WriteString(fb.OPC + "\n\n")
-			}
-		}
+	var orderErr string
+	order, err := graph.TopoOrder()
+	if err != nil {
+		orderErr = err.Error()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nodes":      graph.Nodes,
+		"edges":      graph.Edges,
+		"cycles":     graph.Cycles(),
+		"order":      order,
+		"orderError": orderErr,
+	})
+}
+
+func (s *WebService) GetFBCompleteness(c *gin.Context) {
+	reports, err := s.syncService.GetFBCompletenessReport(c.Query("system"), c.Query("cdsType"), c.Query("node"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"content": content.String(),
-		"count":   len(fbs),
+		"incomplete": reports,
+		"count":      len(reports),
 	})
 }
 
-// Добавить в service.go
+// GetConfig отдает конфиг со скрытыми секретами; ETag передается в If-Match при изменении
 func (s *WebService) GetConfig(c *gin.Context) {
-	config, err := s.syncService.GetConfig()
+	cfg, etag, err := s.syncService.GetConfig()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get config",
-			"details": err.Error(),
-		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, config)
+	c.Header("ETag", etag)
+	c.JSON(http.StatusOK, cfg)
 }
-func (s *WebService) GetNodesBySystem(c *gin.Context) {
-	system := c.Query("system")
-	nodes, err := s.syncService.GetNodesBySystem(system)
+
+// GetConfigSchema отдает JSON Schema конфига
+func (s *WebService) GetConfigSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Schema())
+}
+
+// ReplaceConfig заменяет конфиг целиком (PUT /api/config)
+func (s *WebService) ReplaceConfig(c *gin.Context) {
+	s.updateConfig(c, s.syncService.ReplaceConfig)
+}
+
+// PatchConfig применяет JSON Merge Patch к конфигу (PATCH /api/config)
+func (s *WebService) PatchConfig(c *gin.Context) {
+	s.updateConfig(c, s.syncService.PatchConfig)
+}
+
+// ListConfigVersions отдает историю версий конфига от новых к старым (?limit=N)
+func (s *WebService) ListConfigVersions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	versions, err := s.syncService.ListConfigVersions(limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+		"count":    len(versions),
+	})
+}
+
+// GetConfigVersion отдает версию конфига с изменениями относительно предыдущей;
+// ?against=N сравнивает с версией N, ?against=current - с текущим конфигом
+func (s *WebService) GetConfigVersion(c *gin.Context) {
+	id, ok := versionParam(c)
+	if !ok {
+		return
+	}
+	against := 0
+	switch value := c.Query("against"); value {
+	case "":
+	case "current":
+		against = -1
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid against: " + value})
+			return
+		}
+		against = n
+	}
+	version, err := s.syncService.GetConfigVersion(id, against)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
 
-	c.JSON(http.StatusOK, nodes)
+// RollbackConfig делает текущим конфиг из сохраненной версии (POST /api/config/rollback/:version)
+func (s *WebService) RollbackConfig(c *gin.Context) {
+	id, ok := versionParam(c)
+	if !ok {
+		return
+	}
+	s.updateConfig(c, func(_ []byte, etag, author string) (*config.AppConfig, string, error) {
+		return s.syncService.RollbackConfig(id, etag, author)
+	})
 }
-func (s *WebService) UpdateConfig(c *gin.Context) {
-	var updates map[string]interface{}
-	if err := c.BindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"details": err.Error(),
-		})
+
+// versionParam читает номер версии конфига из пути; при ошибке отвечает 400
+func versionParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("version"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version: " + c.Param("version")})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// updateConfig применяет изменение конфига. Автор версии - заголовок X-Author,
+// по умолчанию адрес клиента
+func (s *WebService) updateConfig(c *gin.Context, apply func(data []byte, etag, author string) (*config.AppConfig, string, error)) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	etag := c.GetHeader("If-Match")
+	if etag == "*" {
+		etag = ""
+	}
+	author := c.GetHeader("X-Author")
+	if author == "" {
+		author = c.ClientIP()
+	}
 
-	if err := s.syncService.UpdateConfig(updates); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update config",
-			"details": err.Error(),
-		})
+	cfg, newTag, err := apply(data, etag, author)
+	if err != nil {
+		var validationErr *sync.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid config", "fields": validationErr.Fields})
+		case errors.Is(err, sync.ErrStale):
+			currentTag, _ := s.syncService.ConfigETag()
+			c.Header("ETag", currentTag)
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		default:
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		}
 		return
 	}
-	// Рассылаем уведомление всем клиентам
+	s.broadcast("config_updated")
+
+	c.Header("ETag", newTag)
+	c.JSON(http.StatusOK, cfg)
+}
+
+// WatchConfig перечитывает файл конфига при изменении до отмены ctx и рассылает
+// клиентам config_reloaded. Настройки БД применяются только после перезапуска
+func (s *WebService) WatchConfig(ctx context.Context, interval time.Duration) {
+	config.Watch(ctx, interval, func(prev, next *config.AppConfig, err error) {
+		if err != nil {
+			log.Printf("Config reload failed, keeping current config: %v", err)
+			return
+		}
+		log.Printf("Config reloaded from %s", config.Path)
+		if prev != nil && prev.DB != nil && next.DB != nil && *prev.DB != *next.DB {
+			log.Println("Warning: database settings changed, restart to apply them")
+		}
+		if _, err := s.syncService.RecordConfigVersion(next, "file", "reloaded from "+config.Path); err != nil {
+			log.Printf("Failed to record config version: %v", err)
+		}
+		s.broadcast("config_reloaded")
+	})
+}
+
+// broadcast рассылает сообщение всем клиентам WebSocket
+func (s *WebService) broadcast(message string) {
 	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
 	for client := range s.clients {
-		if err := client.WriteMessage(websocket.TextMessage, []byte("config_updated")); err != nil {
+		if err := client.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
 			log.Printf("Failed to send WS message: %v", err)
 			delete(s.clients, client)
 			client.Close()
 		}
 	}
-	s.clientsMutex.Unlock()
+}
+func (s *WebService) GetNodesBySystem(c *gin.Context) {
+	system := c.Query("system")
+	nodes, err := s.syncService.GetNodesBySystem(system)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, nodes)
+}
+func (s *WebService) GetFBTagReport(c *gin.Context) {
+	issues, err := s.syncService.GetFBTagReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	c.JSON(http.StatusOK, gin.H{
+		"issues": issues,
+		"count":  len(issues),
+	})
 }
 
 func (s *WebService) TreePage(c *gin.Context) {
@@ -324,6 +513,8 @@ func (s *WebService) getItemDetails(c *gin.Context) {
 		result, err = s.syncService.GetProductDetails(itemID)
 	case "functionblock":
 		result, err = s.syncService.GetFunctionBlockDetails(itemID)
+	case "signal":
+		result, err = s.syncService.GetSignalDetails(itemID)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item type"})
 		return
@@ -336,6 +527,169 @@ func (s *WebService) getItemDetails(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// Search - поиск сигналов и ФБ по тексту с фасетами
+func (s *WebService) Search(c *gin.Context) {
+	var query models.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := s.syncService.Search(query)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *WebService) ListSignals(c *gin.Context) {
+	var filter models.SignalFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	signals, total, err := s.syncService.ListSignals(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"signals": signals,
+		"total":   total,
+	})
+}
+
+func (s *WebService) GetSignal(c *gin.Context) {
+	signal, err := s.syncService.GetSignalInput(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, signal)
+}
+
+// CreateSignal создает сигнал и добавляет его на лист типа сигнала; ?push=false - только в БД
+// до следующей синхронизации
+func (s *WebService) CreateSignal(c *gin.Context) {
+	var input models.SignalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	signal, warnings, err := s.syncService.CreateSignal(input, c.Query("push") != "false")
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"signal":   signal,
+		"warnings": warnings,
+	})
+}
+
+// UpdateSignal обновляет сигнал; поля, отсутствующие в запросе, сохраняют текущие значения.
+// Строка листа тоже обновляется; ?push=false - только в БД до следующей синхронизации.
+func (s *WebService) UpdateSignal(c *gin.Context) {
+	id := c.Param("id")
+	input, err := s.syncService.GetSignalInput(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	signal, warnings, err := s.syncService.UpdateSignal(id, *input, c.Query("push") != "false")
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"signal":   signal,
+		"warnings": warnings,
+	})
+}
+
+// DeleteSignal удаляет сигнал и строку листа; ?push=false - только из БД до следующей синхронизации
+func (s *WebService) DeleteSignal(c *gin.Context) {
+	if err := s.syncService.DeleteSignal(c.Param("id"), c.Query("push") != "false"); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListFunctionBlocks - список ФБ с фильтрами system, cds_type, node
+func (s *WebService) ListFunctionBlocks(c *gin.Context) {
+	fbs, err := s.syncService.ListFunctionBlocks(c.Query("system"), c.Query("cds_type"), c.Query("node"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fbs)
+}
+
+func (s *WebService) GetFunctionBlock(c *gin.Context) {
+	fb, err := s.syncService.GetFunctionBlockDetails(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fb)
+}
+
+// UpdateFunctionBlock изменяет наименование, описание, узел и тип ФБ;
+// поля, отсутствующие в запросе, не изменяются
+func (s *WebService) UpdateFunctionBlock(c *gin.Context) {
+	var update models.FBUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fb, err := s.syncService.UpdateFunctionBlock(c.Param("id"), update)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fb)
+}
+
+// AddFBVariable привязывает вход/выход ФБ к сигналу или к выходу другого ФБ
+func (s *WebService) AddFBVariable(c *gin.Context) {
+	var input models.FBVariableInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fb, err := s.syncService.AddFBVariable(c.Param("id"), input)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, fb)
+}
+
+func (s *WebService) RemoveFBVariable(c *gin.Context) {
+	fb, err := s.syncService.RemoveFBVariable(c.Param("id"), c.Param("varId"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fb)
+}
+
+// RegenerateFunctionBlock перегенерирует код одного ФБ без полной синхронизации
+func (s *WebService) RegenerateFunctionBlock(c *gin.Context) {
+	code, err := s.syncService.RegenerateFunctionBlock(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, code)
+}
+
 func (s *WebService) SyncData(c *gin.Context) {
 	if err := s.syncService.RunFullSync(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -362,3 +716,190 @@ func (s *WebService) RegenerateAllImportFiles(c *gin.Context) {
 		"count":   len(content),
 	})
 }
+
+func (s *WebService) ExportAlarms(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	content, count, err := s.syncService.ExportAlarms(c.Query("system"), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Exported %d alarms", count)
+	sendExport(c, "alarms", format, content)
+}
+
+func (s *WebService) ExportArchive(c *gin.Context) {
+	content, ext, count, err := s.syncService.ExportArchive(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Exported %d archive tags", count)
+	sendExport(c, "archive", ext, content)
+}
+
+func (s *WebService) ExportModbus(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	content, count, err := s.syncService.ExportModbus(c.Query("system"), c.Query("node"), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Exported %d modbus registers", count)
+	sendExport(c, "modbus", format, content)
+}
+
+func (s *WebService) GetModbusMap(c *gin.Context) {
+	registers, issues, err := s.syncService.GetModbusMap(c.Query("system"), c.Query("node"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"registers": registers,
+		"conflicts": issues,
+		"count":     len(registers),
+	})
+}
+
+func (s *WebService) HardwarePage(c *gin.Context) {
+	systems, _ := s.syncService.GetAllSystems()
+	c.HTML(http.StatusOK, "hardware", gin.H{
+		"title":   "Распределение каналов ввода/вывода",
+		"systems": systems,
+	})
+}
+
+func (s *WebService) GetHardwareReport(c *gin.Context) {
+	report, err := s.syncService.GetHardwareReport(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"modules": report,
+		"count":   len(report),
+	})
+}
+
+func (s *WebService) ExportHardware(c *gin.Context) {
+	count, err := s.syncService.ExportHardwareToSheet(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+func (s *WebService) GetModuleReport(c *gin.Context) {
+	catalogue, issues, err := s.syncService.GetModuleReport(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"catalogue": catalogue,
+		"issues":    issues,
+		"count":     len(issues),
+	})
+}
+
+func (s *WebService) NodesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "nodes", gin.H{
+		"title": "Сопоставление узлов",
+	})
+}
+
+func (s *WebService) ListNodeOverrides(c *gin.Context) {
+	overrides, err := s.syncService.ListNodeOverrides()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, overrides)
+}
+
+// SaveNodeOverride создает или заменяет ручное назначение узла по тэгу сигнала или тексту узла
+func (s *WebService) SaveNodeOverride(c *gin.Context) {
+	var override models.NodeOverride
+	if err := c.ShouldBindJSON(&override); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	saved, relinked, err := s.syncService.SaveNodeOverride(override)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"override": saved,
+		"relinked": relinked,
+	})
+}
+
+func (s *WebService) DeleteNodeOverride(c *gin.Context) {
+	if err := s.syncService.DeleteNodeOverride(c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetNodeReview - неуверенные сопоставления узлов последней синхронизации
+func (s *WebService) GetNodeReview(c *gin.Context) {
+	review, err := s.syncService.GetNodeReview()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// GetNodeMatchReport - нечеткие, ручные и отклоненные сопоставления узлов последней синхронизации
+func (s *WebService) GetNodeMatchReport(c *gin.Context) {
+	matches, err := s.syncService.GetNodeMatchReport(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"matches": matches,
+		"count":   len(matches),
+	})
+}
+
+func (s *WebService) ExportNodeMatchReport(c *gin.Context) {
+	count, err := s.syncService.ExportNodeMatchReport(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// errorStatus возвращает HTTP-статус для ошибки сервиса
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, sync.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, sync.ErrInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// sendExport отдает файл выгрузки; тип содержимого определяется по формату
+func sendExport(c *gin.Context, name, format, content string) {
+	contentType := "text/plain; charset=utf-8"
+	switch format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+	case "xml":
+		contentType = "application/xml; charset=utf-8"
+	case "json":
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.Data(http.StatusOK, contentType, []byte(content))
+}
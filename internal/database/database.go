@@ -6,15 +6,33 @@ import (
 	"log"
 	"strings"
 
+	"github.com/glebarez/sqlite"
 	"github.com/mejzh77/astragen/pkg/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// InitDB инициализирует и возвращает подключение к PostgreSQL
-func InitDB(dsn string, cleanBeforeMigrate bool) (*gorm.DB, error) {
+// Драйверы БД
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// InitDB инициализирует и возвращает подключение к PostgreSQL или SQLite
+// (файл или ":memory:")
+func InitDB(driver, dsn string, cleanBeforeMigrate bool) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres, "":
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
 	// Подключение к БД
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
 	})
@@ -35,6 +53,11 @@ func InitDB(dsn string, cleanBeforeMigrate bool) (*gorm.DB, error) {
 	// Настройка пула соединений
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
+	if driver == DriverSQLite {
+		// Одно соединение: БД ":memory:" существует только в пределах соединения,
+		// а файл SQLite не допускает параллельной записи
+		sqlDB.SetMaxOpenConns(1)
+	}
 
 	// Очистка таблиц перед миграцией (если требуется)
 	if cleanBeforeMigrate {
@@ -51,24 +74,31 @@ func InitDB(dsn string, cleanBeforeMigrate bool) (*gorm.DB, error) {
 	if err := AddUniqueConstraints(db); err != nil {
 		return nil, err
 	}
-	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
-		return nil, fmt.Errorf("failed to create pg_trgm extension: %w", err)
+	if IsPostgres(db) {
+		if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+			return nil, fmt.Errorf("failed to create pg_trgm extension: %w", err)
+		}
 	}
-	log.Println("Successfully connected to PostgreSQL database")
+	log.Printf("Successfully connected to %s database", db.Dialector.Name())
 	return db, nil
 }
 
 // cleanDatabase полностью очищает все таблицы в правильном порядке
 func cleanDatabase(db *gorm.DB) error {
 	tables := []string{
+		"interlocks",      // Зависит от function_blocks и signals
 		"fb_variables",    // Зависит от function_blocks
 		"signals",         // Зависит от nodes и products
 		"function_blocks", // Зависит от nodes
+		"node_systems",    // Зависит от nodes и systems
 		"nodes",           // Зависит от systems
 		"products",        // Зависит от systems
 		"systems",         // Зависит от projects
 		"projects",        // Базовая таблица
 	}
+	if !IsPostgres(db) {
+		return cleanSQLite(db, tables)
+	}
 
 	return db.Transaction(func(tx *gorm.DB) error {
 		// Отключаем проверку внешних ключей
@@ -86,6 +116,7 @@ func cleanDatabase(db *gorm.DB) error {
 			}
 		}
 		sequences := []string{
+			"interlocks_id_seq",
 			"fb_variables_id_seq",
 			"signals_id_seq",
 			"function_blocks_id_seq",
@@ -109,6 +140,27 @@ func cleanDatabase(db *gorm.DB) error {
 	})
 }
 
+// cleanSQLite очищает таблицы SQLite: TRUNCATE нет, счетчики AUTOINCREMENT
+// хранятся в sqlite_sequence
+func cleanSQLite(db *gorm.DB, tables []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if tx.Migrator().HasTable(table) {
+				if err := tx.Exec(fmt.Sprintf("DELETE FROM %s", table)).Error; err != nil {
+					return fmt.Errorf("failed to clear table %s: %w", table, err)
+				}
+				log.Printf("Table %s cleared", table)
+			}
+		}
+		if tx.Migrator().HasTable("sqlite_sequence") {
+			if err := tx.Exec("DELETE FROM sqlite_sequence WHERE name IN ?", tables).Error; err != nil {
+				log.Printf("Warning: failed to reset sequences: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
 func AddUniqueConstraints(db *gorm.DB) error {
 	return db.Transaction(func(tx *gorm.DB) error {
 		if !tx.Migrator().HasTable("products") || !tx.Migrator().HasTable("nodes") {
@@ -118,14 +170,14 @@ func AddUniqueConstraints(db *gorm.DB) error {
 		if err := addConstraintIfNotExists(tx,
 			"products",
 			"uc_products_pn_system",
-			"ALTER TABLE products ADD CONSTRAINT uc_products_pn_system UNIQUE (pn, system_id)"); err != nil {
+			"pn, system_id"); err != nil {
 			return err
 		}
 
 		if err := addConstraintIfNotExists(tx,
 			"nodes",
 			"uc_nodes_name_system",
-			"ALTER TABLE nodes ADD CONSTRAINT uc_nodes_name_system UNIQUE (name, system_id)"); err != nil {
+			"name, system_id"); err != nil {
 			return err
 		}
 
@@ -133,7 +185,13 @@ func AddUniqueConstraints(db *gorm.DB) error {
 	})
 }
 
-func addConstraintIfNotExists(db *gorm.DB, table, constraint, sql string) error {
+// addConstraintIfNotExists добавляет ограничение уникальности по колонкам columns.
+// В SQLite ограничение таблицы добавить нельзя, поэтому создается уникальный индекс.
+func addConstraintIfNotExists(db *gorm.DB, table, constraint, columns string) error {
+	sql := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)", table, constraint, columns)
+	if !IsPostgres(db) {
+		sql = fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)", constraint, table, columns)
+	}
 	if !constraintExists(db, table, constraint) {
 		if err := db.Exec(sql).Error; err != nil {
 			if !strings.Contains(err.Error(), "already exists") &&
@@ -147,6 +205,11 @@ func addConstraintIfNotExists(db *gorm.DB, table, constraint, sql string) error
 
 func constraintExists(db *gorm.DB, table, constraint string) bool {
 	var count int
+	if !IsPostgres(db) {
+		db.Raw(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ?`,
+			table, constraint).Scan(&count)
+		return count > 0
+	}
 	db.Raw(`
 		SELECT COUNT(*) 
 		FROM information_schema.table_constraints 
@@ -155,6 +218,12 @@ func constraintExists(db *gorm.DB, table, constraint string) bool {
 	return count > 0
 }
 
+// IsPostgres сообщает, что подключение к PostgreSQL; для SQLite функции pg_trgm
+// и DDL PostgreSQL заменяются переносимыми вариантами
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverPostgres
+}
+
 func autoMigrate(db *gorm.DB) error {
 	modelsToMigrate := []interface{}{
 		&models.Signal{},
@@ -162,8 +231,12 @@ func autoMigrate(db *gorm.DB) error {
 		&models.Node{},
 		&models.FunctionBlock{},
 		&models.FBVariable{},
+		&models.Interlock{},
 		&models.Project{},
 		&models.System{},
+		&models.NodeOverride{},  // Не очищается при запуске
+		&models.ConfigVersion{}, // Не очищается при запуске
+		&models.NodeMatch{},     // Не очищается при запуске, заменяется синхронизацией
 	}
 
 	for _, model := range modelsToMigrate {
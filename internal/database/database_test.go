@@ -0,0 +1,93 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+func openSQLite(t *testing.T, path string, clean bool) *gorm.DB {
+	t.Helper()
+	db, err := InitDB(DriverSQLite, path, clean)
+	if err != nil {
+		t.Fatalf("InitDB(%s, clean=%v): %v", path, clean, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestInitDBSQLiteMigrates(t *testing.T) {
+	db := openSQLite(t, ":memory:", false)
+
+	for _, table := range []string{"signals", "nodes", "function_blocks", "interlocks", "node_overrides", "config_versions", "node_matches"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is not created", table)
+		}
+	}
+	for table, constraint := range map[string]string{"products": "uc_products_pn_system", "nodes": "uc_nodes_name_system"} {
+		if !constraintExists(db, table, constraint) {
+			t.Errorf("unique index %s on %s is not created", constraint, table)
+		}
+	}
+}
+
+func TestInitDBSQLiteClean(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "astragen.db")
+
+	db := openSQLite(t, path, false)
+	project := models.Project{Name: "P"}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Project{Name: "Q"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	override := models.NodeOverride{Kind: models.OverrideByTag, Key: "TAG1", Node: "N1"}
+	if err := db.Create(&override).Error; err != nil {
+		t.Fatal(err)
+	}
+	match := models.NodeMatch{SignalTag: "TAG1", Method: models.NodeMatchFuzzy, Candidates: []models.NodeCandidate{{ID: 1, Name: "N1", Score: 0.5}}}
+	if err := db.Create(&match).Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	// Повторный запуск с очисткой: данные синхронизации удаляются, назначения и сопоставления остаются
+	db = openSQLite(t, path, true)
+	var projects int64
+	db.Model(&models.Project{}).Count(&projects)
+	if projects != 0 {
+		t.Errorf("projects after clean = %d, want 0", projects)
+	}
+	next := models.Project{Name: "R"}
+	if err := db.Create(&next).Error; err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != 1 {
+		t.Errorf("project ID after clean = %d, want 1 (sequence reset)", next.ID)
+	}
+
+	var overrides []models.NodeOverride
+	db.Find(&overrides)
+	if len(overrides) != 1 || overrides[0].Key != "TAG1" {
+		t.Errorf("node overrides after clean = %+v, want TAG1 kept", overrides)
+	}
+	var matches []models.NodeMatch
+	db.Find(&matches)
+	if len(matches) != 1 || len(matches[0].Candidates) != 1 || matches[0].Candidates[0].Name != "N1" {
+		t.Errorf("node matches after clean = %+v, want TAG1 with candidate N1 kept", matches)
+	}
+}
+
+func TestInitDBUnknownDriver(t *testing.T) {
+	if _, err := InitDB("mysql", "", false); err == nil {
+		t.Error("InitDB with unknown driver returned no error")
+	}
+}
@@ -230,6 +230,122 @@ func (s *WriteService) Save(spreadsheetID string, sheetName string, data interfa
 	return nil
 }
 
+// findRow читает лист и возвращает заголовки, номер строки листа (с 1), в которой
+// колонка keyColumn равна key (0 - не найдена), и значения этой строки
+func (s *WriteService) findRow(spreadsheetID, sheetName, keyColumn, key string) ([]string, int, []interface{}, error) {
+	resp, err := s.client.Spreadsheets.Values.Get(spreadsheetID, sheetName).Do()
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read sheet: %w", err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, 0, nil, errors.New("no headers found")
+	}
+
+	var headers []string
+	keyIdx := -1
+	for i, cell := range resp.Values[0] {
+		header := strings.TrimSpace(fmt.Sprintf("%v", cell))
+		headers = append(headers, header)
+		if strings.EqualFold(header, keyColumn) {
+			keyIdx = i
+		}
+	}
+	if keyIdx < 0 {
+		return nil, 0, nil, fmt.Errorf("column %q not found in sheet %s", keyColumn, sheetName)
+	}
+
+	for i, row := range resp.Values[1:] {
+		if keyIdx < len(row) && strings.TrimSpace(fmt.Sprintf("%v", row[keyIdx])) == key {
+			return headers, i + 2, row, nil
+		}
+	}
+	return headers, 0, nil, nil
+}
+
+// UpsertRow обновляет строку листа, в которой колонка keyColumn равна key, или добавляет
+// новую строку в конец листа. Колонки листа, которых нет в item, не изменяются.
+func (s *WriteService) UpsertRow(spreadsheetID, sheetName, keyColumn, key string, item interface{}) error {
+	data, err := Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	if len(data) != 2 {
+		return errors.New("gsheets: expected single struct")
+	}
+
+	headers, rowNum, row, err := s.findRow(spreadsheetID, sheetName, keyColumn, key)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(headers))
+	copy(values, row)
+	for i, header := range headers {
+		for j, column := range data[0] {
+			if strings.EqualFold(header, fmt.Sprintf("%v", column)) {
+				values[i] = data[1][j]
+			}
+		}
+		if values[i] == nil {
+			values[i] = ""
+		}
+	}
+
+	if rowNum == 0 {
+		return s.AppendSheet(spreadsheetID, sheetName, [][]interface{}{values})
+	}
+	rangeData := fmt.Sprintf("%s!A%d:%s%d", sheetName, rowNum, columnToLetter(len(values)), rowNum)
+	_, err = s.client.Spreadsheets.Values.Update(spreadsheetID, rangeData, &sheets.ValueRange{
+		Values: [][]interface{}{values},
+	}).ValueInputOption("RAW").Do()
+	if err != nil {
+		return fmt.Errorf("failed to update row %d: %w", rowNum, err)
+	}
+	return nil
+}
+
+// DeleteRow удаляет строку листа, в которой колонка keyColumn равна key
+func (s *WriteService) DeleteRow(spreadsheetID, sheetName, keyColumn, key string) error {
+	_, rowNum, _, err := s.findRow(spreadsheetID, sheetName, keyColumn, key)
+	if err != nil {
+		return err
+	}
+	if rowNum == 0 {
+		return nil
+	}
+
+	spreadsheet, err := s.client.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return fmt.Errorf("failed to get spreadsheet: %w", err)
+	}
+	var sheetID int64 = -1
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && sheet.Properties.Title == sheetName {
+			sheetID = sheet.Properties.SheetId
+		}
+	}
+	if sheetID < 0 {
+		return fmt.Errorf("sheet %s not found", sheetName)
+	}
+
+	_, err = s.client.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			DeleteDimension: &sheets.DeleteDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    sheetID,
+					Dimension:  "ROWS",
+					StartIndex: int64(rowNum - 1),
+					EndIndex:   int64(rowNum),
+				},
+			},
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("failed to delete row %d: %w", rowNum, err)
+	}
+	return nil
+}
+
 // Load реализует функцию чтения из гугл-таблицы
 func (s *Service) Load(spreadsheetID string, sheetName string, dest interface{}) error {
 	// 1. Получаем ВСЕ заголовки из таблицы
@@ -63,6 +63,15 @@ func (p *Parser) convertValue(value string, field reflect.Value, options map[str
 		field.SetBool(boolVal)
 		return nil
 
+	case reflect.Ptr:
+		// Указатель остается nil для пустой ячейки и отличает ее от нулевого значения
+		elem := reflect.New(field.Type().Elem())
+		if err := p.convertValue(value, elem.Elem(), options); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+
 	case reflect.Struct:
 		if field.Type() == reflect.TypeOf(time.Time{}) {
 			t, err := parseTime(value)
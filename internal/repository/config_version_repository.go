@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+type ConfigVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewConfigVersionRepository(db *gorm.DB) *ConfigVersionRepository {
+	return &ConfigVersionRepository{db: db}
+}
+
+// List возвращает версии конфига от новых к старым без содержимого и изменений
+func (r *ConfigVersionRepository) List(limit int) ([]models.ConfigVersion, error) {
+	var versions []models.ConfigVersion
+	query := r.db.Select("id", "author", "comment", "created_at").Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get config versions: %w", err)
+	}
+	return versions, nil
+}
+
+// Get возвращает версию конфига с содержимым и изменениями
+func (r *ConfigVersionRepository) Get(id uint) (*models.ConfigVersion, error) {
+	var version models.ConfigVersion
+	if err := r.db.First(&version, id).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Latest возвращает последнюю версию конфига; nil - версий нет
+func (r *ConfigVersionRepository) Latest() (*models.ConfigVersion, error) {
+	var version models.ConfigVersion
+	if err := r.db.Order("id DESC").Limit(1).Find(&version).Error; err != nil {
+		return nil, fmt.Errorf("failed to get latest config version: %w", err)
+	}
+	if version.ID == 0 {
+		return nil, nil
+	}
+	return &version, nil
+}
+
+// Create сохраняет новую версию конфига. diff (может быть nil) строит изменения
+// по номеру, присвоенному версии при вставке
+func (r *ConfigVersionRepository) Create(version *models.ConfigVersion, diff func(id uint) string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(version).Error; err != nil {
+			return fmt.Errorf("failed to save config version: %w", err)
+		}
+		if diff == nil {
+			return nil
+		}
+		version.Diff = diff(version.ID)
+		if err := tx.Model(version).Update("diff", version.Diff).Error; err != nil {
+			return fmt.Errorf("failed to save config version diff: %w", err)
+		}
+		return nil
+	})
+}
@@ -7,13 +7,15 @@ import (
 	"strings"
 
 	"github.com/mejzh77/astragen/configs/config"
+	"github.com/mejzh77/astragen/internal/database"
 	"github.com/mejzh77/astragen/pkg/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type FunctionBlockRepository struct {
-	db *gorm.DB
+	db        *gorm.DB
+	cfgSource func() *config.AppConfig // Источник конфига, по умолчанию config.Get
 }
 
 // BulkUpsert создает или обновляет узлы пачкой
@@ -28,10 +30,23 @@ func NewFunctionBlockRepository(db *gorm.DB) *FunctionBlockRepository {
 	if err := createFunctionBlocksTables(db); err != nil {
 		log.Fatalf("Failed to create function blocks tables: %v", err)
 	}
-	return &FunctionBlockRepository{db: db}
+	return &FunctionBlockRepository{db: db, cfgSource: config.Get}
+}
+
+// WithConfig возвращает репозиторий, читающий конфиг cfg, например снимок синхронизации
+func (r *FunctionBlockRepository) WithConfig(cfg *config.AppConfig) *FunctionBlockRepository {
+	return &FunctionBlockRepository{db: r.db, cfgSource: func() *config.AppConfig { return cfg }}
+}
+
+func (r *FunctionBlockRepository) cfg() *config.AppConfig {
+	return r.cfgSource()
 }
 
 func createFunctionBlocksTables(db *gorm.DB) error {
+	if !database.IsPostgres(db) {
+		// Таблицы создает AutoMigrate; DDL ниже рассчитан на PostgreSQL
+		return nil
+	}
 	// Отключаем проверку внешних ключей для безопасного создания
 	if err := db.Exec("SET CONSTRAINTS ALL DEFERRED").Error; err != nil {
 		return err
@@ -87,7 +102,7 @@ func (r *FunctionBlockRepository) GetFBWithVariables(tag string) (*models.Functi
 }
 
 func (r *FunctionBlockRepository) GetAll(fbs *[]models.FunctionBlock) error {
-	return r.db.Preload("System").Find(fbs).Error
+	return r.db.Preload("System").Preload("Node").Find(fbs).Error
 }
 
 func (r *FunctionBlockRepository) DebugCheckFunctionBlocks() {
@@ -102,7 +117,7 @@ func (r *FunctionBlockRepository) DebugCheckFunctionBlocks() {
 
 // Добавляем новые методы в FunctionBlockRepository
 func (r *FunctionBlockRepository) GetFiltered(system, cdsType, node string) ([]*models.FunctionBlock, error) {
-	query := r.db.Preload("Variables").Preload("System").Preload("Node")
+	query := r.db.Preload("Variables.SourceFB").Preload("System").Preload("Node")
 
 	if system != "" {
 		query = query.
@@ -118,8 +133,13 @@ func (r *FunctionBlockRepository) GetFiltered(system, cdsType, node string) ([]*
 		query = query.Joins("JOIN nodes ON nodes.id = function_blocks.node_id").
 			Where("nodes.name = ?", node)
 	}
+	// Порядок по префиксу адреса и номеру канала; в SQLite нет регулярных выражений - по адресу
+	order := "node_id, substring(address from '^(.*\\.)'), cast(regexp_replace(substring(address from '\\.[^\\d.]*\\d+$'), '^\\.(CH0|CH)*', '', 'g') as integer)"
+	if !database.IsPostgres(r.db) {
+		order = "node_id, address"
+	}
 	var fbs []*models.FunctionBlock
-	if err := query.Order("node_id, substring(address from '^(.*\\.)'), cast(regexp_replace(substring(address from '\\.[^\\d.]*\\d+$'), '^\\.(CH0|CH)*', '', 'g') as integer)").Find(&fbs).Error; err != nil {
+	if err := query.Order(order).Find(&fbs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get filtered FBs: %w", err)
 	}
 
@@ -170,7 +190,7 @@ func (r *FunctionBlockRepository) GetByNode(nodeID uint) ([]*models.FunctionBloc
 	return fbs, nil
 }
 func (r *FunctionBlockRepository) SyncInputsFromSignals(signals []models.Signal) error {
-	fbConfigs := config.Cfg.FunctionBlocks
+	fbConfigs := r.cfg().FunctionBlocks
 
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// Первый проход: создаем/обновляем FB и переменные
@@ -193,7 +213,7 @@ func (r *FunctionBlockRepository) SyncInputsFromSignals(signals []models.Signal)
 				continue
 			}
 
-			fb, err := models.ParseFromSignal(signal, config.Cfg.AddressTemplate[signal.SignalType])
+			fb, err := models.ParseFromSignal(signal, r.cfg().AddressTemplate[signal.SignalType])
 			if err != nil {
 				continue
 			}
@@ -221,7 +241,7 @@ func (r *FunctionBlockRepository) SyncInputsFromSignals(signals []models.Signal)
 			fbConfig := fbConfigs[fb.CdsType]
 
 			// Генерируем контент
-			if err := r.GenerateFBContent(fb, fbConfig, &config.Cfg.DefaultOPCItem); err != nil {
+			if err := r.GenerateFBContent(fb, fbConfig, &r.cfg().DefaultOPCItem); err != nil {
 				return err
 			}
 
@@ -229,6 +249,7 @@ func (r *FunctionBlockRepository) SyncInputsFromSignals(signals []models.Signal)
 			if err := tx.Model(fb).Updates(map[string]interface{}{
 				"declaration": fb.Declaration,
 				"call":        fb.Call,
+				"init":        fb.Init,
 				"omx":         fb.OMX,
 				"opc":         fb.OPC,
 			}).Error; err != nil {
@@ -240,46 +261,33 @@ func (r *FunctionBlockRepository) SyncInputsFromSignals(signals []models.Signal)
 	})
 }
 func (r *FunctionBlockRepository) SyncFBFromSignals(signals []models.Signal) error {
-	fbConfigs := config.Cfg.FunctionBlocks
+	fbConfigs := r.cfg().FunctionBlocks
 
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// Первый проход: создаем/обновляем FB и переменные
 		fbCache := make(map[string]*models.FunctionBlock)
 		var fbTags []string
 
+		rules, err := compileTagRules(fbConfigs)
+		if err != nil {
+			return err
+		}
+
 		for _, signal := range signals {
-			_, funcAttr, ok := models.ParseFBInfo(signal.Tag)
-			if !ok || signal.FB == "" {
+			if signal.FB == "" {
 				continue
 			}
-
 			fbConfig, exists := fbConfigs[signal.FB]
 			if !exists {
 				continue
 			}
-			invertedIn := make(map[string]bool)
-			for _, v := range fbConfig.In {
-				parts := strings.Split(v, ".")
-				invertedIn[parts[0]] = true
-			}
-
-			invertedOut := make(map[string]bool)
-			for _, v := range fbConfig.Out {
-				parts := strings.Split(v, ".")
-				invertedOut[parts[0]] = true
-			}
 
-			// Теперь проверяем за O(1)
-			var direction string
-			if invertedIn[funcAttr] {
-				direction = "input"
-			} else if invertedOut[funcAttr] {
-				direction = "output"
-			} else {
+			fbTag, funcAttr, direction, reason := resolveFBSignal(signal.Tag, fbConfig, rules[signal.FB])
+			if reason != "" {
 				continue
 			}
 
-			fb, variable, err := models.ParseFBFromSignal(signal, direction, config.Cfg.AddressTemplate[signal.SignalType])
+			fb, variable, err := models.ParseFBFromSignal(signal, fbTag, funcAttr, direction, r.cfg().AddressTemplate[signal.SignalType])
 			if err != nil {
 				fmt.Printf("failed to parse FB %s: %v", signal.Tag, err)
 				continue
@@ -319,7 +327,7 @@ func (r *FunctionBlockRepository) SyncFBFromSignals(signals []models.Signal) err
 
 			// Загружаем все переменные для этого FB
 			var variables []models.FBVariable
-			if err := tx.Where("fb_id = ?", fb.ID).Find(&variables).Error; err != nil {
+			if err := tx.Preload("Signal").Preload("SourceFB").Where("fb_id = ?", fb.ID).Find(&variables).Error; err != nil {
 				return fmt.Errorf("failed to load variables for FB %s: %w", fb.Tag, err)
 			}
 
@@ -327,7 +335,7 @@ func (r *FunctionBlockRepository) SyncFBFromSignals(signals []models.Signal) err
 			fb.Variables = variables
 
 			// Генерируем контент
-			if err := r.GenerateFBContent(fb, fbConfig, &config.Cfg.DefaultOPCItem); err != nil {
+			if err := r.GenerateFBContent(fb, fbConfig, &r.cfg().DefaultOPCItem); err != nil {
 				return err
 			}
 
@@ -335,6 +343,7 @@ func (r *FunctionBlockRepository) SyncFBFromSignals(signals []models.Signal) err
 			if err := tx.Model(fb).Updates(map[string]interface{}{
 				"declaration": fb.Declaration,
 				"call":        fb.Call,
+				"init":        fb.Init,
 				"omx":         fb.OMX,
 				"opc":         fb.OPC,
 			}).Error; err != nil {
@@ -345,6 +354,105 @@ func (r *FunctionBlockRepository) SyncFBFromSignals(signals []models.Signal) err
 		return nil
 	})
 }
+
+// compileTagRules компилирует правила разбора тэгов для всех типов ФБ
+func compileTagRules(fbConfigs map[string]config.FBConfig) (map[string][]*models.FBTagRule, error) {
+	rules := make(map[string][]*models.FBTagRule)
+	for cdsType, fbConfig := range fbConfigs {
+		compiled, err := fbConfig.CompileTagRules()
+		if err != nil {
+			return nil, fmt.Errorf("FB %s: %w", cdsType, err)
+		}
+		rules[cdsType] = compiled
+	}
+	return rules, nil
+}
+
+// resolveFBSignal определяет тэг ФБ, атрибут и направление переменной для
+// сигнала составного ФБ. Если сигнал не подходит ни под одно правило,
+// возвращает причину в reason
+func resolveFBSignal(signalTag string, fbConfig config.FBConfig, rules []*models.FBTagRule) (fbTag, funcAttr, direction, reason string) {
+	pinDirection := func(attr string) string {
+		for _, v := range fbConfig.In {
+			if strings.Split(v, ".")[0] == attr {
+				return "input"
+			}
+		}
+		for _, v := range fbConfig.Out {
+			if strings.Split(v, ".")[0] == attr {
+				return "output"
+			}
+		}
+		return ""
+	}
+
+	if len(rules) == 0 {
+		var ok bool
+		if fbTag, funcAttr, ok = models.ParseFBInfo(signalTag); !ok {
+			return "", "", "", "tag has no '_' separator"
+		}
+		if direction = pinDirection(funcAttr); direction == "" {
+			return fbTag, funcAttr, "", fmt.Sprintf("attribute %q is not a pin", funcAttr)
+		}
+		return fbTag, funcAttr, direction, ""
+	}
+
+	reason = "no tag rule matched"
+	for _, rule := range rules {
+		tag, attr, ok := rule.Parse(signalTag)
+		if !ok {
+			continue
+		}
+		if dir := pinDirection(attr); dir != "" {
+			return tag, attr, dir, ""
+		}
+		fbTag, funcAttr = tag, attr
+		reason = fmt.Sprintf("attribute %q is not a pin", attr)
+	}
+	return fbTag, funcAttr, "", reason
+}
+
+// CheckTagRules возвращает сигналы составных ФБ, которые не разбираются
+// правилами tag_rules или не соответствуют входам/выходам ФБ
+func (r *FunctionBlockRepository) CheckTagRules(signals []models.Signal) ([]models.FBTagIssue, error) {
+	fbConfigs := r.cfg().FunctionBlocks
+	rules, err := compileTagRules(fbConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []models.FBTagIssue
+	for _, signal := range signals {
+		if signal.FB == "" {
+			continue
+		}
+		fbConfig, exists := fbConfigs[signal.FB]
+		if !exists {
+			issues = append(issues, models.FBTagIssue{
+				SignalTag: signal.Tag,
+				CdsType:   signal.FB,
+				Reason:    "FB type is not configured",
+			})
+			continue
+		}
+		// Первичные типы (AI, DI, ...) привязываются по адресу, а не по атрибуту
+		if signal.FB == signal.SignalType {
+			continue
+		}
+		fbTag, funcAttr, _, reason := resolveFBSignal(signal.Tag, fbConfig, rules[signal.FB])
+		if reason != "" {
+			issues = append(issues, models.FBTagIssue{
+				SignalTag: signal.Tag,
+				CdsType:   signal.FB,
+				FBTag:     fbTag,
+				FuncAttr:  funcAttr,
+				Reason:    reason,
+			})
+		}
+	}
+	return issues, nil
+}
+
 func (r *FunctionBlockRepository) GenerateFBContent(fb *models.FunctionBlock, fbConfig config.FBConfig, opcTemplate *config.OPCItemTemplate) error {
 	stDecl, err := fb.GenerateSTDecl()
 	if err != nil {
@@ -353,12 +461,19 @@ func (r *FunctionBlockRepository) GenerateFBContent(fb *models.FunctionBlock, fb
 	fb.Declaration = stDecl
 
 	// Генерация ST-кода
-	stCode, err := fb.GenerateSTCode(fbConfig.Template, fbConfig.In, fbConfig.Out)
+	stCode, err := fb.GenerateSTCode(fbConfig.Template, fbConfig.In, fbConfig.Out, fbConfig.Defaults)
 	if err != nil {
 		return fmt.Errorf("failed to generate ST code for FB %s: %w", fb.Tag, err)
 	}
 	fb.Call = stCode
 
+	// Генерация инициализации параметров
+	stInit, err := fb.GenerateSTInit(fbConfig.Params, fbConfig.In, fbConfig.Out)
+	if err != nil {
+		return fmt.Errorf("failed to generate ST init for FB %s: %w", fb.Tag, err)
+	}
+	fb.Init = stInit
+
 	// Генерация OMX
 	omxCode, err := fb.GenerateOMX(fbConfig.OMX.Template, fbConfig.OMX.Attributes)
 	if err != nil {
@@ -394,14 +509,14 @@ func (r *FunctionBlockRepository) UpdateAddresses() error {
 		Select("signals.*, fb.*").
 		Joins("JOIN fb_variables v ON v.signal_tag = signals.tag").
 		Joins("JOIN function_blocks fb ON v.fb_id = fb.id").
-		Where("fb.primary = ?", true).
+		Where(`fb."primary" = ?`, true).
 		Find(&signals)
 	if result.Error != nil {
 		return fmt.Errorf("ошибка получения сигналов: %v", result.Error)
 	}
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		for _, s := range signals {
-			newAddress, err := models.UpdateAddress(s.Signal, config.Cfg.AddressTemplate[s.Signal.SignalType])
+			newAddress, err := models.UpdateAddress(s.Signal, r.cfg().AddressTemplate[s.Signal.SignalType])
 			if err != nil {
 				// Можно добавить логирование и продолжить
 				log.Printf("Ошибка обновления адреса для сигнала %s: %v", s.Signal.Tag, err)
@@ -410,7 +525,7 @@ func (r *FunctionBlockRepository) UpdateAddresses() error {
 
 			// 3. Обновляем только primary = true блоки
 			result := tx.Model(&models.FunctionBlock{}).
-				Where("id = ? AND primary = ?", s.FB.ID, true).
+				Where(`id = ? AND "primary" = ?`, s.FB.ID, true).
 				Update("address", newAddress)
 
 			if result.Error != nil {
@@ -428,12 +543,18 @@ func (r *FunctionBlockRepository) RegenerateAllImportFiles() (map[string]map[str
 		return nil, fmt.Errorf("failed to get all FBs: %w", err)
 	}
 	var fbs []*models.FunctionBlock
-	if err := r.db.Preload("Variables").Find(&fbs).Error; err != nil {
+	if err := r.db.Preload("Variables.Signal").Preload("Variables.SourceFB").Preload("Node").Preload("System").Find(&fbs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get all FBs: %w", err)
 	}
+	if err := r.attachSourceSignals(fbs); err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(fbs); err != nil {
+		return nil, err
+	}
 
 	result := make(map[string]map[string]string)
-	fbConfigs := config.Cfg.FunctionBlocks
+	fbConfigs := r.cfg().FunctionBlocks
 
 	for _, fb := range fbs {
 		fbConfig, exists := fbConfigs[fb.CdsType]
@@ -442,20 +563,22 @@ func (r *FunctionBlockRepository) RegenerateAllImportFiles() (map[string]map[str
 		}
 
 		// Генерируем содержимое
-		if err := r.GenerateFBContent(fb, fbConfig, &config.Cfg.DefaultOPCItem); err != nil {
+		if err := r.GenerateFBContent(fb, fbConfig, &r.cfg().DefaultOPCItem); err != nil {
 			return nil, err
 		}
 
 		// Формируем результат
 		result[fb.Tag] = map[string]string{
-			"ST":  fb.Call,
-			"OMX": fb.OMX,
-			"OPC": fb.OPC,
+			"ST":     fb.Call,
+			"STInit": fb.Init,
+			"OMX":    fb.OMX,
+			"OPC":    fb.OPC,
 		}
 
 		// Обновляем в БД (опционально)
 		if err := r.db.Model(fb).Updates(map[string]interface{}{
 			"call": fb.Call,
+			"init": fb.Init,
 			"omx":  fb.OMX,
 			"opc":  fb.OPC,
 		}).Error; err != nil {
@@ -465,8 +588,409 @@ func (r *FunctionBlockRepository) RegenerateAllImportFiles() (map[string]map[str
 
 	return result, nil
 }
+
+// attachSourceSignals подгружает исходные сигналы первичных ФБ для шаблонов
+func (r *FunctionBlockRepository) attachSourceSignals(fbs []*models.FunctionBlock) error {
+	var tags []string
+	for _, fb := range fbs {
+		if fb.Primary {
+			tags = append(tags, fb.Tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var signals []models.Signal
+	if err := r.db.Preload("Product").Where("tag IN ?", tags).Find(&signals).Error; err != nil {
+		return fmt.Errorf("failed to load source signals: %w", err)
+	}
+	byTag := make(map[string]*models.Signal, len(signals))
+	for i := range signals {
+		byTag[signals[i].Tag] = &signals[i]
+	}
+	for _, fb := range fbs {
+		if fb.Primary {
+			fb.Signal = byTag[fb.Tag]
+		}
+	}
+	return nil
+}
+
 func (r *FunctionBlockRepository) GetWithDetails(id string, fb *models.FunctionBlock) error {
 	return r.db.
-		Preload("Variables").
+		Preload("System").
+		Preload("Node").
+		Preload("Variables.SourceFB").
 		First(fb, id).Error
 }
+
+// GetByTag возвращает ФБ по тэгу
+func (r *FunctionBlockRepository) GetByTag(tag string) (*models.FunctionBlock, error) {
+	var fb models.FunctionBlock
+	if err := r.db.Where("tag = ?", tag).First(&fb).Error; err != nil {
+		return nil, fmt.Errorf("failed to get FB %s: %w", tag, err)
+	}
+	return &fb, nil
+}
+
+// UpdateFields обновляет заданные поля ФБ
+func (r *FunctionBlockRepository) UpdateFields(fb *models.FunctionBlock, fields map[string]interface{}) error {
+	if err := r.db.Model(fb).Updates(fields).Error; err != nil {
+		return fmt.Errorf("failed to update FB %s: %w", fb.Tag, err)
+	}
+	return nil
+}
+
+// AddVariable сохраняет привязку входа/выхода ФБ
+func (r *FunctionBlockRepository) AddVariable(variable *models.FBVariable) error {
+	if err := r.db.Create(variable).Error; err != nil {
+		return fmt.Errorf("failed to create FB variable %s: %w", variable.FuncAttr, err)
+	}
+	return nil
+}
+
+// GetVariable возвращает переменную ФБ по ID
+func (r *FunctionBlockRepository) GetVariable(fbID uint, id string) (*models.FBVariable, error) {
+	var variable models.FBVariable
+	if err := r.db.Preload("SourceFB").Where("fb_id = ?", fbID).First(&variable, id).Error; err != nil {
+		return nil, err
+	}
+	return &variable, nil
+}
+
+// DeleteVariable удаляет переменную ФБ
+func (r *FunctionBlockRepository) DeleteVariable(variable *models.FBVariable) error {
+	if err := r.db.Unscoped().Delete(variable).Error; err != nil {
+		return fmt.Errorf("failed to delete FB variable %s: %w", variable.FuncAttr, err)
+	}
+	return nil
+}
+
+// Regenerate перегенерирует ST, OMX и OPC одного ФБ и сохраняет результат
+func (r *FunctionBlockRepository) Regenerate(id uint) (*models.FunctionBlock, error) {
+	var fb models.FunctionBlock
+	if err := r.db.Preload("Variables.Signal").Preload("Variables.SourceFB").Preload("Node").Preload("System").
+		First(&fb, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get FB: %w", err)
+	}
+	fbConfig, exists := r.cfg().FunctionBlocks[fb.CdsType]
+	if !exists {
+		return nil, fmt.Errorf("no configuration for cds_type %q", fb.CdsType)
+	}
+
+	fbs := []*models.FunctionBlock{&fb}
+	if err := r.attachSourceSignals(fbs); err != nil {
+		return nil, err
+	}
+	if err := r.attachLinks(fbs); err != nil {
+		return nil, err
+	}
+	if err := r.GenerateFBContent(&fb, fbConfig, &r.cfg().DefaultOPCItem); err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&fb).Updates(map[string]interface{}{
+		"declaration": fb.Declaration,
+		"call":        fb.Call,
+		"init":        fb.Init,
+		"omx":         fb.OMX,
+		"opc":         fb.OPC,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update FB content %s: %w", fb.Tag, err)
+	}
+	return &fb, nil
+}
+
+// DeletePrimary удаляет первичный ФБ сигнала вместе со связями других ФБ с ним
+func (r *FunctionBlockRepository) DeletePrimary(tag string) error {
+	var fb models.FunctionBlock
+	err := r.db.Where(`tag = ? AND "primary" = ?`, tag, true).Limit(1).Find(&fb).Error
+	if err != nil {
+		return fmt.Errorf("failed to get primary FB %s: %w", tag, err)
+	}
+	if fb.ID == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("fb_id = ? OR source_fb_id = ?", fb.ID, fb.ID).
+			Delete(&models.FBVariable{}).Error; err != nil {
+			return fmt.Errorf("failed to delete variables of primary FB %s: %w", tag, err)
+		}
+		if err := tx.Unscoped().Delete(&fb).Error; err != nil {
+			return fmt.Errorf("failed to delete primary FB %s: %w", tag, err)
+		}
+		return nil
+	})
+}
+
+// UpsertSoftware создает или обновляет программный ФБ, объявленный в листе FB
+func (r *FunctionBlockRepository) UpsertSoftware(fb *models.FunctionBlock) error {
+	fb.Software = true
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tag"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"cds_type", "system_id", "node_id", "node_ref", "wiring", "bindings",
+			"name", "description", "software", "updated_at",
+		}),
+	}).Create(fb).Error
+}
+
+// UpdateSheetFields обновляет поля ФБ, редактируемые в листе FB
+func (r *FunctionBlockRepository) UpdateSheetFields(tag, name, description, wiring, bindings string) error {
+	return r.db.Model(&models.FunctionBlock{}).
+		Where("tag = ?", tag).
+		Updates(map[string]interface{}{
+			"name":        name,
+			"description": description,
+			"wiring":      wiring,
+			"bindings":    bindings,
+		}).Error
+}
+
+// DeleteSoftwareExcept удаляет программные ФБ, которых больше нет в листе FB,
+// вместе с их переменными и блокировками
+func (r *FunctionBlockRepository) DeleteSoftwareExcept(tags []string) (int64, error) {
+	query := r.db.Model(&models.FunctionBlock{}).Where("software = ?", true)
+	if len(tags) > 0 {
+		query = query.Where("tag NOT IN ?", tags)
+	}
+	var ids []uint
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to find software FBs: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("fb_id IN ? OR source_fb_id IN ?", ids, ids).
+			Delete(&models.FBVariable{}).Error; err != nil {
+			return fmt.Errorf("failed to delete software FB variables: %w", err)
+		}
+		// Блокировки с удаленными ФБ пересоздаются из листа следующим шагом синхронизации
+		if err := tx.Unscoped().Where("effect_fb_id IN ? OR cause_fb_id IN ?", ids, ids).
+			Delete(&models.Interlock{}).Error; err != nil {
+			return fmt.Errorf("failed to delete interlocks of software FBs: %w", err)
+		}
+		result := tx.Unscoped().Delete(&models.FunctionBlock{}, ids)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete software FBs: %w", result.Error)
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
+
+// SyncWiring пересоздает переменные-связи ФБ-ФБ по явному подключению из листа FB.
+// Ссылки на неизвестные тэги остаются выражениями (см. attachLinks).
+func (r *FunctionBlockRepository) SyncWiring() error {
+	var fbs []models.FunctionBlock
+	if err := r.db.Select("id", "tag", "cds_type", "wiring").Find(&fbs).Error; err != nil {
+		return fmt.Errorf("failed to load FBs: %w", err)
+	}
+	byTag := make(map[string]*models.FunctionBlock, len(fbs))
+	for i := range fbs {
+		byTag[fbs[i].Tag] = &fbs[i]
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("source_fb_id IS NOT NULL").
+			Delete(&models.FBVariable{}).Error; err != nil {
+			return fmt.Errorf("failed to delete FB links: %w", err)
+		}
+
+		for _, fb := range fbs {
+			if fb.Wiring == "" {
+				continue
+			}
+			wiring, err := models.ParseWiring(fb.Wiring)
+			if err != nil {
+				log.Printf("FB %s: %v", fb.Tag, err)
+				continue
+			}
+			out := r.cfg().FunctionBlocks[fb.CdsType].Out
+
+			for pin, ref := range wiring {
+				tag, sourcePin := models.SplitWiringRef(ref)
+				source, ok := byTag[tag]
+				if !ok {
+					continue
+				}
+				direction := "input"
+				if _, ok := out[pin]; ok {
+					direction = "output"
+				}
+				link := models.FBVariable{
+					FBID:       fb.ID,
+					Direction:  direction,
+					CdsType:    source.CdsType,
+					FuncAttr:   pin,
+					SourceFBID: &source.ID,
+					SourcePin:  sourcePin,
+				}
+				if err := tx.Create(&link).Error; err != nil {
+					return fmt.Errorf("failed to create link %s.%s: %w", fb.Tag, pin, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SyncBindings применяет ручные привязки сигналов из листа FB поверх переменных,
+// созданных из сигналов: "attr=TAG" заменяет сигнал входа/выхода, "attr=-" отвязывает его
+func (r *FunctionBlockRepository) SyncBindings() error {
+	var fbs []models.FunctionBlock
+	if err := r.db.Select("id", "tag", "cds_type", "bindings").
+		Where("bindings IS NOT NULL AND bindings <> ''").Find(&fbs).Error; err != nil {
+		return fmt.Errorf("failed to load FBs: %w", err)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, fb := range fbs {
+			bindings, err := models.ParseWiring(fb.Bindings)
+			if err != nil {
+				log.Printf("FB %s: %v", fb.Tag, err)
+				continue
+			}
+			fbConfig := r.cfg().FunctionBlocks[fb.CdsType]
+
+			for attr, tag := range bindings {
+				in := models.FBVariableInput{FuncAttr: attr, SignalTag: tag}
+				direction, err := in.ResolveDirection(fbConfig.In, fbConfig.Out)
+				if err != nil {
+					log.Printf("FB %s: %v", fb.Tag, err)
+					continue
+				}
+				if err := tx.Unscoped().
+					Where("fb_id = ? AND func_attr = ? AND direction = ? AND signal_tag IS NOT NULL", fb.ID, attr, direction).
+					Delete(&models.FBVariable{}).Error; err != nil {
+					return fmt.Errorf("failed to unbind %s.%s: %w", fb.Tag, attr, err)
+				}
+				if tag == models.FBBindingNone {
+					continue
+				}
+
+				var signals []models.Signal
+				if err := tx.Where("tag = ?", tag).Limit(1).Find(&signals).Error; err != nil {
+					return fmt.Errorf("failed to get signal %s: %w", tag, err)
+				}
+				if len(signals) == 0 {
+					log.Printf("FB %s: signal %q for %s not found", fb.Tag, tag, attr)
+					continue
+				}
+				signal := signals[0]
+				address, err := models.UpdateAddress(signal, r.cfg().AddressTemplate[signal.SignalType])
+				if err != nil {
+					log.Printf("FB %s: %v", fb.Tag, err)
+					continue
+				}
+				variable := models.FBVariable{
+					FBID:      fb.ID,
+					Direction: direction,
+					CdsType:   signal.SignalType,
+					Address:   address,
+					FuncAttr:  attr,
+					SignalTag: &signal.Tag,
+				}
+				if err := tx.Create(&variable).Error; err != nil {
+					return fmt.Errorf("failed to bind %s.%s: %w", fb.Tag, attr, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GenerateWiredFBs генерирует содержимое программных ФБ и ФБ с явным подключением
+// или ручными привязками сигналов
+func (r *FunctionBlockRepository) GenerateWiredFBs() error {
+	if err := r.SyncWiring(); err != nil {
+		return err
+	}
+	if err := r.SyncBindings(); err != nil {
+		return err
+	}
+
+	var fbs []*models.FunctionBlock
+	if err := r.db.Preload("Variables.Signal").Preload("Variables.SourceFB").Preload("Node").Preload("System").
+		Where("software = ? OR (wiring IS NOT NULL AND wiring <> '') OR (bindings IS NOT NULL AND bindings <> '')", true).
+		Find(&fbs).Error; err != nil {
+		return fmt.Errorf("failed to load wired FBs: %w", err)
+	}
+	if err := r.attachLinks(fbs); err != nil {
+		return err
+	}
+
+	for _, fb := range fbs {
+		fbConfig, exists := r.cfg().FunctionBlocks[fb.CdsType]
+		if !exists {
+			log.Printf("FB %s: unknown cds_type %q, skipping generation", fb.Tag, fb.CdsType)
+			continue
+		}
+		if err := r.GenerateFBContent(fb, fbConfig, &r.cfg().DefaultOPCItem); err != nil {
+			return err
+		}
+		if err := r.db.Model(fb).Updates(map[string]interface{}{
+			"declaration": fb.Declaration,
+			"call":        fb.Call,
+			"init":        fb.Init,
+			"omx":         fb.OMX,
+			"opc":         fb.OPC,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update FB content %s: %w", fb.Tag, err)
+		}
+	}
+	return nil
+}
+
+// attachLinks подставляет явное подключение ФБ, не ссылающееся на другие ФБ
+// (константы, глобальные переменные). Ссылки на ФБ хранятся как переменные-связи.
+func (r *FunctionBlockRepository) attachLinks(fbs []*models.FunctionBlock) error {
+	wirings := make(map[*models.FunctionBlock]map[string]string)
+	var tags []string
+	for _, fb := range fbs {
+		if fb.Wiring == "" {
+			continue
+		}
+		wiring, err := models.ParseWiring(fb.Wiring)
+		if err != nil {
+			return fmt.Errorf("FB %s: %w", fb.Tag, err)
+		}
+		wirings[fb] = wiring
+		tags = append(tags, models.WiringTags(wiring)...)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var known []string
+	if err := r.db.Model(&models.FunctionBlock{}).Where("tag IN ?", tags).Pluck("tag", &known).Error; err != nil {
+		return fmt.Errorf("failed to load wired FBs: %w", err)
+	}
+	isFB := make(map[string]bool, len(known))
+	for _, tag := range known {
+		isFB[tag] = true
+	}
+
+	for fb, wiring := range wirings {
+		fb.Links = make(map[string]string)
+		for pin, ref := range wiring {
+			if tag, _ := models.SplitWiringRef(ref); !isFB[tag] {
+				fb.Links[pin] = ref
+			}
+		}
+	}
+	return nil
+}
+
+// GetGraph возвращает граф зависимостей ФБ с учетом фильтров
+func (r *FunctionBlockRepository) GetGraph(system, cdsType, node string) (*models.FBGraph, error) {
+	fbs, err := r.GetFiltered(system, cdsType, node)
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildFBGraph(fbs), nil
+}
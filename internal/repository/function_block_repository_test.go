@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mejzh77/astragen/configs/config"
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+func TestCheckTagRules(t *testing.T) {
+	valve := config.FBConfig{
+		In:  map[string]string{"i_xOpen": "open.VALUE", "i_xClose": "close.VALUE"},
+		Out: map[string]string{"q_xCmd": "cmd.VALUE"},
+	}
+	withRules := valve
+	withRules.TagRules = []config.TagRuleConfig{{Template: "{fb}.{attr}_A1"}, {Pattern: `^(?P<fb>[A-Z]+\d+)-(?P<attr>[a-z]+)$`}}
+	r := (&FunctionBlockRepository{}).WithConfig(&config.AppConfig{FunctionBlocks: map[string]config.FBConfig{
+		"VLV":  valve,
+		"VLVR": withRules,
+		"DI":   {In: map[string]string{"i_xVal": "address"}},
+	}})
+
+	tests := []struct {
+		name   string
+		signal models.Signal
+		want   []models.FBTagIssue
+	}{
+		{"default rule match", models.Signal{Tag: "VLV101_open", FB: "VLV"}, nil},
+		{"default rule output", models.Signal{Tag: "VLV101_cmd", FB: "VLV"}, nil},
+		{"no FB type", models.Signal{Tag: "X_open"}, nil},
+		{"primary type", models.Signal{Tag: "TS101", FB: "DI", SignalType: "DI"}, nil},
+		{
+			name:   "default rule no separator",
+			signal: models.Signal{Tag: "VLV101", FB: "VLV"},
+			want:   []models.FBTagIssue{{SignalTag: "VLV101", CdsType: "VLV", Reason: "tag has no '_' separator"}},
+		},
+		{
+			name:   "default rule attribute is not a pin",
+			signal: models.Signal{Tag: "VLV101_stop", FB: "VLV"},
+			want: []models.FBTagIssue{{SignalTag: "VLV101_stop", CdsType: "VLV", FBTag: "VLV101", FuncAttr: "stop",
+				Reason: `attribute "stop" is not a pin`}},
+		},
+		{"template match", models.Signal{Tag: "VLV101.close_A1", FB: "VLVR"}, nil},
+		{"pattern match", models.Signal{Tag: "VLV101-open", FB: "VLVR"}, nil},
+		{
+			name:   "template miss",
+			signal: models.Signal{Tag: "VLV101_close", FB: "VLVR"},
+			want:   []models.FBTagIssue{{SignalTag: "VLV101_close", CdsType: "VLVR", Reason: "no tag rule matched"}},
+		},
+		{
+			name:   "rule match attribute is not a pin",
+			signal: models.Signal{Tag: "VLV101.stop_A1", FB: "VLVR"},
+			want: []models.FBTagIssue{{SignalTag: "VLV101.stop_A1", CdsType: "VLVR", FBTag: "VLV101", FuncAttr: "stop",
+				Reason: `attribute "stop" is not a pin`}},
+		},
+		{
+			name:   "unknown FB type",
+			signal: models.Signal{Tag: "M101_run", FB: "MOTOR"},
+			want:   []models.FBTagIssue{{SignalTag: "M101_run", CdsType: "MOTOR", Reason: "FB type is not configured"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.CheckTagRules([]models.Signal{tt.signal})
+			if err != nil {
+				t.Fatalf("CheckTagRules(%s): %v", tt.signal.Tag, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckTagRules(%s) = %+v, want %+v", tt.signal.Tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTagRulesInvalidRule(t *testing.T) {
+	r := (&FunctionBlockRepository{}).WithConfig(&config.AppConfig{FunctionBlocks: map[string]config.FBConfig{
+		"VLV": {TagRules: []config.TagRuleConfig{{Template: "{fb}"}}},
+	}})
+	if _, err := r.CheckTagRules([]models.Signal{{Tag: "VLV101_open", FB: "VLV"}}); err == nil {
+		t.Error("CheckTagRules with a rule without attr group returned no error")
+	}
+}
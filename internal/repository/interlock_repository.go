@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+type InterlockRepository struct {
+	db *gorm.DB
+}
+
+func NewInterlockRepository(db *gorm.DB) *InterlockRepository {
+	return &InterlockRepository{db: db}
+}
+
+// ReplaceAll заменяет все блокировки загруженными из листа
+func (r *InterlockRepository) ReplaceAll(interlocks []models.Interlock) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&models.Interlock{}).Error; err != nil {
+			return fmt.Errorf("failed to clear interlocks: %w", err)
+		}
+		if len(interlocks) == 0 {
+			return nil
+		}
+		if err := tx.Create(&interlocks).Error; err != nil {
+			return fmt.Errorf("failed to save interlocks: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetFiltered возвращает блокировки по системе и узлу ФБ-исполнителя
+func (r *InterlockRepository) GetFiltered(system, node string) ([]models.Interlock, error) {
+	query := r.db.Preload("CauseFB").Preload("CauseSignal").Preload("EffectFB").Preload("Node")
+
+	if system != "" {
+		query = query.
+			Joins("JOIN systems ON systems.id = interlocks.system_id").
+			Where("systems.name = ?", system)
+	}
+	if node != "" {
+		query = query.
+			Joins("JOIN nodes ON nodes.id = interlocks.node_id").
+			Where("nodes.name = ?", node)
+	}
+
+	var interlocks []models.Interlock
+	if err := query.Order("interlocks.node_id, interlocks.priority, interlocks.tag").Find(&interlocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get interlocks: %w", err)
+	}
+	return interlocks, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+type NodeMatchRepository struct {
+	db *gorm.DB
+}
+
+func NewNodeMatchRepository(db *gorm.DB) *NodeMatchRepository {
+	return &NodeMatchRepository{db: db}
+}
+
+// ReplaceAll заменяет сопоставления узлов предыдущей синхронизации
+func (r *NodeMatchRepository) ReplaceAll(matches []models.NodeMatch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.NodeMatch{}).Error; err != nil {
+			return fmt.Errorf("failed to clear node matches: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&matches, 500).Error; err != nil {
+			return fmt.Errorf("failed to save node matches: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAll возвращает сопоставления узлов последней синхронизации
+func (r *NodeMatchRepository) GetAll() ([]models.NodeMatch, error) {
+	var matches []models.NodeMatch
+	if err := r.db.Order("id").Find(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node matches: %w", err)
+	}
+	return matches, nil
+}
+
+// GetReport возвращает неточные сопоставления узлов по системе (пусто - все системы)
+func (r *NodeMatchRepository) GetReport(system string) ([]models.NodeMatch, error) {
+	query := r.db.Where("method <> ?", models.NodeMatchExact)
+	if system != "" {
+		query = query.Where("system = ?", system)
+	}
+	matches := []models.NodeMatch{}
+	if err := query.Order("system, node_ref, signal_tag").Find(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node match report: %w", err)
+	}
+	return matches, nil
+}
+
+// SetOverride отмечает сопоставления как ручное назначение узла node
+func (r *NodeMatchRepository) SetOverride(ids []uint, node string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := r.db.Model(&models.NodeMatch{}).
+		Where("id IN ?", ids).
+		Select("node", "method", "score", "candidates").
+		Updates(models.NodeMatch{Node: node, Method: models.NodeMatchOverride, Score: 1}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update node matches: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NodeOverrideRepository struct {
+	db *gorm.DB
+}
+
+func NewNodeOverrideRepository(db *gorm.DB) *NodeOverrideRepository {
+	return &NodeOverrideRepository{db: db}
+}
+
+// GetAll возвращает все ручные назначения узлов
+func (r *NodeOverrideRepository) GetAll() ([]models.NodeOverride, error) {
+	var overrides []models.NodeOverride
+	if err := r.db.Order("kind, system, key").Find(&overrides).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// Upsert создает назначение или заменяет узел существующего назначения с тем же ключом
+func (r *NodeOverrideRepository) Upsert(override *models.NodeOverride) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "key"}, {Name: "system"}},
+		DoUpdates: clause.AssignmentColumns([]string{"node", "comment", "updated_at"}),
+	}).Create(override).Error
+	if err != nil {
+		return fmt.Errorf("failed to save node override %s: %w", override.Key, err)
+	}
+	return nil
+}
+
+// Delete удаляет назначение по ID
+func (r *NodeOverrideRepository) Delete(id string) (bool, error) {
+	result := r.db.Delete(&models.NodeOverride{}, id)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete node override: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"fmt"
 
+	"github.com/mejzh77/astragen/internal/database"
 	"github.com/mejzh77/astragen/pkg/models"
 
 	"gorm.io/gorm"
@@ -71,22 +72,40 @@ func (r *NodeRepository) LinkFunctionBlock(node *models.Node, fb *models.Functio
 	return r.db.Model(node).Association("FunctionBlocks").Append(fb)
 }
 
-// В node_repository.go
-func (r *NodeRepository) FindSimilarInSystem(name string, systemID uint) ([]models.Node, error) {
-	var nodes []models.Node
+// FindSimilarInSystem возвращает до трех узлов системы, наиболее похожих на имя, с оценкой сходства
+// (similarity() из pg_trgm; для SQLite - TrigramMatcher)
+func (r *NodeRepository) FindSimilarInSystem(name string, systemID uint) ([]models.NodeCandidate, error) {
+	var candidates []models.NodeCandidate
+	if !database.IsPostgres(r.db) {
+		nodes, err := r.GetInSystem(systemID)
+		if err != nil {
+			return nil, err
+		}
+		return models.RankNodes(models.TrigramMatcher{}, name, nodes, 3), nil
+	}
 
-	// Используем полнотекстовый поиск или similarity-функции
-	// Пример для PostgreSQL:
-	query := `SELECT * FROM nodes
-         		JOIN public.node_systems ns on nodes.id = ns.node_id 
-         		WHERE ns.system_id = ? 
-              ORDER BY similarity(name, ?) DESC 
+	query := `SELECT nodes.id, nodes.name, similarity(nodes.name, ?) AS score FROM nodes
+         		JOIN node_systems ns on nodes.id = ns.node_id 
+         		WHERE ns.system_id = ? AND nodes.deleted_at IS NULL
+              ORDER BY score DESC 
               LIMIT 3`
 
-	if err := r.db.Raw(query, systemID, name).Scan(&nodes).Error; err != nil {
+	if err := r.db.Raw(query, name, systemID).Scan(&candidates).Error; err != nil {
 		return nil, err
 	}
 
+	return candidates, nil
+}
+
+// GetInSystem возвращает узлы, связанные с системой
+func (r *NodeRepository) GetInSystem(systemID uint) ([]models.Node, error) {
+	var nodes []models.Node
+	err := r.db.Joins("JOIN node_systems ON node_systems.node_id = nodes.id").
+		Where("node_systems.system_id = ?", systemID).
+		Find(&nodes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes of system: %w", err)
+	}
 	return nodes, nil
 }
 
@@ -98,7 +117,7 @@ func (r *NodeRepository) BulkUpsert(nodes []models.Node) error {
 	}).Create(&nodes).Error
 }
 
-// FindByNameAndSystem ищет узел по точному совпадению имени и системы
+// FindByName ищет узел по точному совпадению имени в любой системе
 func (r *NodeRepository) FindByName(name string) (*models.Node, error) {
 	var node models.Node
 	err := r.db.Where("name = ?", name).First(&node).Error
@@ -108,6 +127,29 @@ func (r *NodeRepository) FindByName(name string) (*models.Node, error) {
 	return &node, nil
 }
 
+// FindByNameAndSystem ищет узел по точному совпадению имени среди узлов системы:
+// созданных в ней и связанных с ней через node_systems
+func (r *NodeRepository) FindByNameAndSystem(name string, systemID uint) (*models.Node, error) {
+	var node models.Node
+	err := r.db.Where("name = ?", name).
+		Where("system_id = ? OR id IN (?)", systemID,
+			r.db.Table("node_systems").Select("node_id").Where("system_id = ?", systemID)).
+		First(&node).Error
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// FindAllByName возвращает узлы с точным совпадением имени во всех системах
+func (r *NodeRepository) FindAllByName(name string) ([]models.Node, error) {
+	var nodes []models.Node
+	if err := r.db.Where("name = ?", name).Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("failed to find nodes: %w", err)
+	}
+	return nodes, nil
+}
+
 // Create создает новый узел
 func (r *NodeRepository) Create(node *models.Node) error {
 	return r.db.Create(node).Error
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mejzh77/astragen/internal/database"
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+// Порог word_similarity (pg_trgm) для нечеткого совпадения текста
+const searchSimilarity = 0.3
+
+var searchSortColumns = map[string]string{
+	"score":  "score",
+	"tag":    "items.tag",
+	"name":   "items.name",
+	"system": "items.system",
+	"node":   "items.node",
+	"type":   "items.signal_type || items.cds_type",
+}
+
+var searchFacetColumns = map[string]string{
+	"kind":        "items.kind",
+	"system":      "items.system",
+	"node":        "items.node",
+	"signal_type": "items.signal_type",
+	"cds_type":    "items.cds_type",
+	"product":     "items.product",
+	"crate":       "items.crate",
+}
+
+type SearchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// items возвращает объединенную выборку сигналов и ФБ с общим набором колонок
+func (r *SearchRepository) items() *gorm.DB {
+	signals := r.db.Table("signals").
+		Select(`'signal' AS kind, signals.id, signals.tag,
+			COALESCE(signals.name, '') AS name, COALESCE(signals.comment, '') AS comment,
+			COALESCE(signals.equipment, '') AS equipment,
+			COALESCE(systems.name, '') AS system, COALESCE(nodes.name, '') AS node,
+			COALESCE(signals.signal_type, '') AS signal_type, '' AS cds_type,
+			COALESCE(products.tag, '') AS product, COALESCE(signals.crate, '') AS crate`).
+		Joins("LEFT JOIN systems ON systems.id = signals.system_id").
+		Joins("LEFT JOIN nodes ON nodes.id = signals.node_id").
+		Joins("LEFT JOIN products ON products.id = signals.product_id").
+		Where("signals.deleted_at IS NULL")
+
+	fbs := r.db.Table("function_blocks").
+		Select(`'functionblock' AS kind, function_blocks.id, function_blocks.tag,
+			COALESCE(function_blocks.name, '') AS name,
+			TRIM(COALESCE(function_blocks.description, '') || ' ' || COALESCE(function_blocks.comment, '')) AS comment,
+			COALESCE(function_blocks.equipment, '') AS equipment,
+			COALESCE(systems.name, '') AS system, COALESCE(nodes.name, '') AS node,
+			'' AS signal_type, COALESCE(function_blocks.cds_type, '') AS cds_type,
+			'' AS product, '' AS crate`).
+		Joins("LEFT JOIN systems ON systems.id = function_blocks.system_id").
+		Joins("LEFT JOIN nodes ON nodes.id = function_blocks.node_id").
+		Where("function_blocks.deleted_at IS NULL")
+
+	return r.db.Table("(? UNION ALL ?) AS items", signals, fbs)
+}
+
+// like возвращает оператор поиска подстроки без учета регистра; в SQLite LIKE
+// не учитывает регистр только для латиницы и требует явного ESCAPE
+func (r *SearchRepository) like() string {
+	if database.IsPostgres(r.db) {
+		return "ILIKE ?"
+	}
+	return `LIKE ? ESCAPE '\'`
+}
+
+// filter применяет текст и фильтры фасетов, кроме фасета skip
+func (r *SearchRepository) filter(query *gorm.DB, q models.SearchQuery, skip string) *gorm.DB {
+	if q.Text != "" {
+		like := "%" + escapeLike(q.Text) + "%"
+		op := r.like()
+		cond := "items.tag " + op + " OR items.name " + op + " OR items.comment " + op + " OR items.equipment " + op
+		if database.IsPostgres(r.db) {
+			query = query.Where(cond+
+				` OR word_similarity(?, items.tag || ' ' || items.name || ' ' || items.comment || ' ' || items.equipment) >= ?`,
+				like, like, like, like, q.Text, searchSimilarity)
+		} else {
+			query = query.Where(cond, like, like, like, like)
+		}
+	}
+	for _, facet := range models.SearchFacets {
+		if facet == skip {
+			continue
+		}
+		if value := q.FacetValue(facet); value != "" {
+			query = query.Where(searchFacetColumns[facet]+" = ?", value)
+		}
+	}
+	return query
+}
+
+// Search ищет сигналы и ФБ по тексту (тэг, наименование, комментарий, оборудование)
+// с фильтрами, сортировкой и постраничным выводом; фасеты считаются без учета
+// собственного фильтра фасета
+func (r *SearchRepository) Search(q models.SearchQuery) (*models.SearchResult, error) {
+	q.Normalize()
+	result := &models.SearchResult{
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Facets:   make(map[string][]models.FacetCount),
+	}
+
+	if err := r.filter(r.items(), q, "").Count(&result.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	score := "0"
+	var scoreArgs []interface{}
+	if q.Text != "" && database.IsPostgres(r.db) {
+		score = `GREATEST(similarity(items.tag, ?),
+			word_similarity(?, items.tag || ' ' || items.name || ' ' || items.comment || ' ' || items.equipment))
+			+ CASE WHEN items.tag ILIKE ? THEN 1 ELSE 0 END`
+		scoreArgs = []interface{}{q.Text, q.Text, escapeLike(q.Text)}
+	} else if q.Text != "" {
+		// Без pg_trgm: точное совпадение тэга, затем совпадение подстроки в тэге
+		score = "CASE WHEN items.tag " + r.like() + " THEN 2 WHEN items.tag " + r.like() + " THEN 1 ELSE 0 END"
+		scoreArgs = []interface{}{escapeLike(q.Text), "%" + escapeLike(q.Text) + "%"}
+	}
+	column, ok := searchSortColumns[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", q.Sort)
+	}
+
+	hits := r.filter(r.items(), q, "").
+		Select("items.*, "+score+" AS score", scoreArgs...).
+		Order(column + " " + strings.ToUpper(q.Order)).
+		Order("items.tag").
+		Limit(q.PageSize).
+		Offset((q.Page - 1) * q.PageSize)
+	if err := hits.Scan(&result.Hits).Error; err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	if result.Hits == nil {
+		result.Hits = []models.SearchHit{}
+	}
+
+	for _, facet := range models.SearchFacets {
+		column := searchFacetColumns[facet]
+		var counts []models.FacetCount
+		err := r.filter(r.items(), q, facet).
+			Select(column + " AS value, COUNT(*) AS count").
+			Where(column + " <> ''").
+			Group(column).
+			Order("count DESC").
+			Order(column).
+			Scan(&counts).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to count facet %s: %w", facet, err)
+		}
+		result.Facets[facet] = counts
+	}
+	return result, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -21,6 +21,155 @@ func NewSignalRepository(db *gorm.DB) *SignalRepository {
 	return &SignalRepository{db: db}
 }
 
+// GetByTags возвращает сигналы по списку тэгов
+func (r *SignalRepository) GetByTags(tags []string) ([]models.Signal, error) {
+	var signals []models.Signal
+	if len(tags) == 0 {
+		return signals, nil
+	}
+	if err := r.db.Where("tag IN ?", tags).Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signals: %w", err)
+	}
+	return signals, nil
+}
+
+// GetWithDetails возвращает сигнал по ID с системой, узлом и изделием
+func (r *SignalRepository) GetWithDetails(id string, signal *models.Signal) error {
+	return r.db.
+		Preload("System").
+		Preload("Node").
+		Preload("Product").
+		First(signal, id).Error
+}
+
+// GetForExport возвращает сигналы заданных типов (все, если не указаны) с узлом и системой
+func (r *SignalRepository) GetForExport(system string, signalTypes []string) ([]models.Signal, error) {
+	query := r.db.Preload("Node").Preload("System").Preload("Product")
+	if system != "" {
+		query = query.
+			Joins("JOIN systems ON systems.id = signals.system_id").
+			Where("systems.name = ?", system)
+	}
+	if len(signalTypes) > 0 {
+		query = query.Where("signals.signal_type IN ?", signalTypes)
+	}
+
+	var signals []models.Signal
+	if err := query.Order("signals.tag").Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signals for export: %w", err)
+	}
+	return signals, nil
+}
+
+// GetFiltered возвращает страницу сигналов по фильтру и общее количество
+func (r *SignalRepository) GetFiltered(filter models.SignalFilter) ([]models.Signal, int64, error) {
+	query := r.db.Model(&models.Signal{})
+	if filter.System != "" {
+		query = query.
+			Joins("JOIN systems ON systems.id = signals.system_id").
+			Where("systems.name = ?", filter.System)
+	}
+	if filter.Node != "" {
+		query = query.
+			Joins("JOIN nodes ON nodes.id = signals.node_id").
+			Where("nodes.name = ?", filter.Node)
+	}
+	if filter.Product != "" {
+		query = query.
+			Joins("JOIN products ON products.id = signals.product_id").
+			Where("products.name = ? OR products.tag = ?", filter.Product, filter.Product)
+	}
+	if filter.SignalType != "" {
+		query = query.Where("signals.signal_type = ?", filter.SignalType)
+	}
+	if filter.Crate != "" {
+		query = query.Where("signals.crate = ?", filter.Crate)
+	}
+	if filter.Module != "" {
+		query = query.Where("signals.module = ?", filter.Module)
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count signals: %w", err)
+	}
+
+	if filter.PageSize > 0 {
+		query = query.Limit(filter.PageSize).Offset((filter.Page - 1) * filter.PageSize)
+	}
+	var signals []models.Signal
+	err := query.Preload("Node").Preload("System").Preload("Product").
+		Order("signals.tag").
+		Find(&signals).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get signals: %w", err)
+	}
+	return signals, total, nil
+}
+
+// Create сохраняет новый сигнал
+func (r *SignalRepository) Create(signal *models.Signal) error {
+	signal.Tag = normalizeString(signal.Tag)
+	signal.NodeRef = truncateUTF8(normalizeString(signal.NodeRef), 255)
+	if err := r.db.Omit(clause.Associations).Create(signal).Error; err != nil {
+		return fmt.Errorf("failed to create signal %s: %w", signal.Tag, err)
+	}
+	return nil
+}
+
+// Update обновляет поля сигнала, загружаемые из листа его типа
+func (r *SignalRepository) Update(signal *models.Signal) error {
+	signal.NodeRef = truncateUTF8(normalizeString(signal.NodeRef), 255)
+	err := r.db.Model(signal).
+		Omit(clause.Associations).
+		Select(getUpdateColumnsForSignalType(signal.SignalType)).
+		Updates(signal).Error
+	if err != nil {
+		return fmt.Errorf("failed to update signal %s: %w", signal.Tag, err)
+	}
+	return nil
+}
+
+// Delete удаляет сигнал безвозвратно, чтобы тэг можно было использовать повторно
+func (r *SignalRepository) Delete(signal *models.Signal) error {
+	if err := r.db.Unscoped().Delete(signal).Error; err != nil {
+		return fmt.Errorf("failed to delete signal %s: %w", signal.Tag, err)
+	}
+	return nil
+}
+
+// GetReferences возвращает тэги ФБ и блокировок, ссылающихся на сигнал
+func (r *SignalRepository) GetReferences(tag string) ([]string, error) {
+	var refs []string
+	err := r.db.Model(&models.FBVariable{}).
+		Joins("JOIN function_blocks ON function_blocks.id = fb_variables.fb_id").
+		Where("fb_variables.signal_tag = ?", tag).
+		Distinct().
+		Pluck("function_blocks.tag", &refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal references: %w", err)
+	}
+
+	var interlocks []string
+	err = r.db.Model(&models.Interlock{}).
+		Where("cause_signal_tag = ?", tag).
+		Pluck("tag", &interlocks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal references: %w", err)
+	}
+	return append(refs, interlocks...), nil
+}
+
+// GetWithFBType возвращает сигналы, для которых указан тип ФБ
+func (r *SignalRepository) GetWithFBType() ([]models.Signal, error) {
+	var signals []models.Signal
+	if err := r.db.Where("fb <> ''").Order("tag").Find(&signals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signals: %w", err)
+	}
+	return signals, nil
+}
+
 func (r *SignalRepository) SaveSignals(signals []models.Signal, debug bool) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		for i, signal := range signals {
@@ -53,6 +202,7 @@ func getUpdateColumnsForSignalType(signalType string) []string {
 	// Базовые поля, общие для всех типов сигналов
 	baseFields := []string{
 		"system_id", "equipment", "name", "module", "channel",
+		"module_type", "module_channels", "module_addressing",
 		"crate", "place", "property", "address", "modbus_addr", "node_id",
 		"node_ref", "fb", "check_status", "comment", "updated_at",
 		"value", "product_id",
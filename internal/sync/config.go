@@ -0,0 +1,209 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/mejzh77/astragen/configs/config"
+)
+
+// GetConfig возвращает конфиг со скрытыми секретами и его ETag
+func (s *SyncService) GetConfig() (*config.AppConfig, string, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, "", fmt.Errorf("config not loaded")
+	}
+	etag, err := configETag(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	public, err := cfg.Redacted()
+	if err != nil {
+		return nil, "", err
+	}
+	return public, etag, nil
+}
+
+// ConfigETag возвращает ETag текущего конфига
+func (s *SyncService) ConfigETag() (string, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return "", fmt.Errorf("config not loaded")
+	}
+	return configETag(cfg)
+}
+
+// ReplaceConfig заменяет конфиг целиком (PUT): отсутствующие поля становятся пустыми.
+// etag - If-Match клиента; пустой - без проверки. author записывается в историю версий
+func (s *SyncService) ReplaceConfig(data []byte, etag, author string) (*config.AppConfig, string, error) {
+	return s.updateConfig(etag, author, "", func(current *config.AppConfig) (*config.AppConfig, error) {
+		return decodeConfigFrom(current, data)
+	})
+}
+
+// PatchConfig применяет к конфигу JSON Merge Patch (RFC 7396): отсутствующие поля
+// не меняются, null удаляет поле или ключ словаря
+func (s *SyncService) PatchConfig(patch []byte, etag, author string) (*config.AppConfig, string, error) {
+	return s.updateConfig(etag, author, "", func(current *config.AppConfig) (*config.AppConfig, error) {
+		currentJSON, err := json.Marshal(current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		var doc, p interface{}
+		if err := json.Unmarshal(currentJSON, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode config: %w", err)
+		}
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if _, ok := p.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: patch must be a JSON object", ErrInvalid)
+		}
+		data, err := json.Marshal(mergePatch(doc, p))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+		return decodeConfigFrom(current, data)
+	})
+}
+
+// updateConfig проверяет ETag, строит новый конфиг из текущего, проверяет его,
+// сохраняет в файл, делает текущим и записывает версию в историю
+func (s *SyncService) updateConfig(etag, author, comment string, build func(current *config.AppConfig) (*config.AppConfig, error)) (*config.AppConfig, string, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	next, err := config.Update(func(current *config.AppConfig) (*config.AppConfig, error) {
+		currentTag, err := configETag(current)
+		if err != nil {
+			return nil, err
+		}
+		if etag != "" && etag != currentTag {
+			return nil, fmt.Errorf("%w: config ETag is %s", ErrStale, currentTag)
+		}
+
+		next, err := build(current)
+		if err != nil {
+			return nil, err
+		}
+		fields := next.KeepProtected(current)
+		fields = append(fields, next.Validate()...)
+		if len(fields) > 0 {
+			return nil, &ValidationError{Fields: fields}
+		}
+		return next, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := s.recordConfigVersion(next, author, comment); err != nil {
+		log.Printf("Warning: config saved, but its version was not recorded: %v", err)
+	}
+
+	newTag, err := configETag(next)
+	if err != nil {
+		return nil, "", err
+	}
+	public, err := next.Redacted()
+	if err != nil {
+		return nil, "", err
+	}
+	return public, newTag, nil
+}
+
+// decodeConfigFrom разбирает JSON нового конфига и переносит из current поля, не передаваемые в JSON
+func decodeConfigFrom(current *config.AppConfig, data []byte) (*config.AppConfig, error) {
+	next, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	next.Inherit(current)
+	return next, nil
+}
+
+// decodeConfig разбирает JSON конфига; неизвестные поля и неверные типы - ошибки полей
+func decodeConfig(data []byte) (*config.AppConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg config.AppConfig
+	if err := dec.Decode(&cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ValidationError{Fields: []config.FieldError{{
+				Path:    typeErr.Field,
+				Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+			}}}
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, &ValidationError{Fields: []config.FieldError{{
+				Path:    strings.Trim(field, `"`),
+				Message: "unknown field",
+			}}}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return &cfg, nil
+}
+
+// jsonTypeName возвращает тип JSON, соответствующий типу Go, для сообщения об ошибке
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// mergePatch применяет JSON Merge Patch к разобранному документу
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+// configETag - хэш содержимого конфига для оптимистичной блокировки. Хэшируется копия
+// со скрытыми секретами: ETag отдается клиенту и не должен зависеть от паролей
+func configETag(cfg *config.AppConfig) (string, error) {
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`, nil
+}
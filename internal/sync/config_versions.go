@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mejzh77/astragen/configs/config"
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+// RecordConfigVersion записывает конфиг в историю, если он отличается от последней
+// версии (перезагрузка файла, запуск после правки файла вручную)
+func (s *SyncService) RecordConfigVersion(cfg *config.AppConfig, author, comment string) (*models.ConfigVersion, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	return s.recordConfigVersion(cfg, author, comment)
+}
+
+// recordConfigVersion сохраняет версию с изменениями относительно предыдущей;
+// nil - конфиг совпадает с последней версией
+func (s *SyncService) recordConfigVersion(cfg *config.AppConfig, author, comment string) (*models.ConfigVersion, error) {
+	content, err := cfg.MarshalRedacted()
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.versionRepo.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.ConfigVersion{
+		Author:  author,
+		Comment: comment,
+		Content: string(content),
+	}
+	var diff func(id uint) string
+	if latest != nil {
+		if latest.Content == version.Content {
+			return nil, nil
+		}
+		// Номер новой версии известен только после вставки: ID могут идти с пропусками
+		diff = func(id uint) string {
+			return models.UnifiedDiff(fmt.Sprintf("v%d", latest.ID), fmt.Sprintf("v%d", id), latest.Content, version.Content)
+		}
+	}
+	if err := s.versionRepo.Create(version, diff); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// ListConfigVersions возвращает историю версий конфига от новых к старым
+func (s *SyncService) ListConfigVersions(limit int) ([]models.ConfigVersion, error) {
+	return s.versionRepo.List(limit)
+}
+
+// GetConfigVersion возвращает версию конфига. against - версия для сравнения:
+// 0 - предыдущая (сохраненные изменения), -1 - текущий конфиг
+func (s *SyncService) GetConfigVersion(id uint, against int) (*models.ConfigVersion, error) {
+	version, err := s.getConfigVersion(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case against < 0:
+		current := config.Get()
+		if current == nil {
+			return nil, fmt.Errorf("config not loaded")
+		}
+		content, err := current.MarshalRedacted()
+		if err != nil {
+			return nil, err
+		}
+		version.Diff = models.UnifiedDiff(fmt.Sprintf("v%d", version.ID), "current", version.Content, string(content))
+	case against > 0:
+		other, err := s.getConfigVersion(uint(against))
+		if err != nil {
+			return nil, err
+		}
+		version.Diff = models.UnifiedDiff(fmt.Sprintf("v%d", other.ID), fmt.Sprintf("v%d", version.ID), other.Content, version.Content)
+	}
+	return version, nil
+}
+
+// RollbackConfig делает текущим конфиг из версии id и записывает его как новую версию.
+// Секреты не откатываются: скрытые в истории значения берутся из текущего конфига
+func (s *SyncService) RollbackConfig(id uint, etag, author string) (*config.AppConfig, string, error) {
+	version, err := s.getConfigVersion(id)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.updateConfig(etag, author, fmt.Sprintf("rollback to version %d", id), func(*config.AppConfig) (*config.AppConfig, error) {
+		cfg, err := config.Parse([]byte(version.Content))
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %d: %v", ErrInvalid, id, err)
+		}
+		return cfg, nil
+	})
+}
+
+func (s *SyncService) getConfigVersion(id uint) (*models.ConfigVersion, error) {
+	version, err := s.versionRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: config version %d", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get config version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,35 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mejzh77/astragen/configs/config"
+)
+
+var (
+	// ErrNotFound - запрошенный объект не найден
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid - данные запроса не прошли проверку
+	ErrInvalid = errors.New("invalid request")
+	// ErrStale - объект изменен другим клиентом (не совпал ETag)
+	ErrStale = errors.New("modified by another client")
+)
+
+// ValidationError - ошибки проверки отдельных полей; errors.Is(err, ErrInvalid) истинно
+type ValidationError struct {
+	Fields []config.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Error())
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalid, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalid
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// ExportAlarms формирует таблицу аварийных сообщений SCADA в заданном формате
+func (s *SyncService) ExportAlarms(system, format string) (string, int, error) {
+	tmpl, ok := s.cfg().Alarms.Formats[format]
+	if !ok {
+		return "", 0, fmt.Errorf("alarm export format %q is not configured", format)
+	}
+
+	signals, err := s.signalRepo.GetForExport(system, []string{"AI", "DI"})
+	if err != nil {
+		return "", 0, err
+	}
+	rows := models.BuildAlarms(signals, s.cfg().Alarms.Settings())
+
+	content, err := models.RenderTable(tmpl.Header, tmpl.Row, tmpl.Footer, rows)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to render alarms: %w", err)
+	}
+	return content, len(rows), nil
+}
+
+// ExportArchive формирует конфигурацию архива historian; возвращает содержимое,
+// расширение файла и количество тэгов
+func (s *SyncService) ExportArchive(system string) (string, string, int, error) {
+	cfg := s.cfg().Archive
+	if cfg.Format.Row == "" {
+		return "", "", 0, fmt.Errorf("archive export format is not configured")
+	}
+
+	signals, err := s.signalRepo.GetForExport(system, nil)
+	if err != nil {
+		return "", "", 0, err
+	}
+	fbs, err := s.fbRepo.GetFiltered(system, "", "")
+	if err != nil {
+		return "", "", 0, err
+	}
+	rows := models.BuildArchive(signals, fbs, cfg.ArchiveRules())
+
+	content, err := models.RenderTable(cfg.Format.Header, cfg.Format.Row, cfg.Format.Footer, rows)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to render archive: %w", err)
+	}
+	ext := cfg.Extension
+	if ext == "" {
+		ext = "csv"
+	}
+	return content, ext, len(rows), nil
+}
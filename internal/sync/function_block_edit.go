@@ -0,0 +1,308 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+// ListFunctionBlocks возвращает ФБ по фильтрам без сгенерированного кода
+func (s *SyncService) ListFunctionBlocks(system, cdsType, node string) ([]gin.H, error) {
+	fbs, err := s.fbRepo.GetFiltered(system, cdsType, node)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gin.H, 0, len(fbs))
+	for _, fb := range fbs {
+		result = append(result, fb.ToSummaryAPI())
+	}
+	return result, nil
+}
+
+// UpdateFunctionBlock изменяет наименование, описание, узел и тип ФБ и перегенерирует его.
+// Изменения записываются и в лист FB, иначе следующая синхронизация вернет значения листа.
+// Тип ФБ из сигналов задается их колонкой fb, поэтому изменить можно только тип программного ФБ.
+func (s *SyncService) UpdateFunctionBlock(id string, update models.FBUpdate) (gin.H, error) {
+	fb, err := s.getFunctionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	row := fb.SheetRow()
+	if update.Name != nil {
+		row.Name = strings.TrimSpace(*update.Name)
+		fields["name"] = row.Name
+	}
+	if update.Description != nil {
+		row.Description = strings.TrimSpace(*update.Description)
+		fields["description"] = row.Description
+	}
+	if update.CdsType != nil {
+		cdsType := strings.TrimSpace(*update.CdsType)
+		if _, ok := s.cfg().FunctionBlocks[cdsType]; !ok {
+			return nil, fmt.Errorf("%w: unknown cds_type %q", ErrInvalid, cdsType)
+		}
+		if cdsType != fb.CdsType && !fb.Software {
+			return nil, fmt.Errorf("%w: cds_type of %s is set by its signals", ErrInvalid, fb.Tag)
+		}
+		row.CdsType = cdsType
+		fields["cds_type"] = cdsType
+	}
+	if update.Node != nil {
+		name := strings.TrimSpace(*update.Node)
+		if name == "" {
+			fields["node_id"] = nil
+			fields["node_ref"] = ""
+		} else {
+			node, err := s.nodeRepo.FindByName(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unknown node %q", ErrInvalid, name)
+			}
+			name = node.Name
+			fields["node_id"] = node.ID
+			fields["node_ref"] = node.Name
+		}
+		row.Node = name
+	}
+	if len(fields) > 0 {
+		if err := s.pushFunctionBlock(fb, row); err != nil {
+			return nil, err
+		}
+		if err := s.fbRepo.UpdateFields(fb, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.regenerate(fb); err != nil {
+		return nil, err
+	}
+	return s.GetFunctionBlockDetails(id)
+}
+
+// AddFBVariable привязывает вход/выход ФБ к сигналу или выходу другого ФБ.
+// Связь ФБ-ФБ также записывается в подключение (wiring) ФБ и в колонку pins листа FB,
+// привязка сигнала - в колонку signals; из них привязки восстанавливаются при синхронизации.
+// Первичных ФБ в листе нет, поэтому их сигналы не меняются.
+func (s *SyncService) AddFBVariable(id string, in models.FBVariableInput) (gin.H, error) {
+	fb, err := s.getFunctionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	fbConfig := s.cfg().FunctionBlocks[fb.CdsType]
+	direction, err := in.ResolveDirection(fbConfig.In, fbConfig.Out)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for _, v := range fb.Variables {
+		if v.FuncAttr == in.FuncAttr && v.Direction == direction {
+			return nil, fmt.Errorf("%w: %s %s is already bound to %s", ErrInvalid, direction, in.FuncAttr, v.SourceTag())
+		}
+	}
+
+	variable := &models.FBVariable{
+		FBID:      fb.ID,
+		Direction: direction,
+		FuncAttr:  in.FuncAttr,
+	}
+	if in.IsLink() {
+		source, err := s.fbRepo.GetByTag(in.Source)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown function block %q", ErrInvalid, in.Source)
+		}
+		if source.ID == fb.ID {
+			return nil, fmt.Errorf("%w: function block cannot be linked to itself", ErrInvalid)
+		}
+		variable.SourceFBID = &source.ID
+		variable.SourcePin = in.SourcePin
+		variable.CdsType = source.CdsType
+
+		wiring, err := models.ParseWiring(fb.Wiring)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		ref := source.Tag
+		if in.SourcePin != "" {
+			ref += "." + in.SourcePin
+		}
+		wiring[in.FuncAttr] = ref
+		if err := s.updateWiring(fb, wiring); err != nil {
+			return nil, err
+		}
+	} else {
+		if fb.Primary {
+			return nil, fmt.Errorf("%w: signals of primary function block %s are set by its signal", ErrInvalid, fb.Tag)
+		}
+		// При синхронизации направление привязки из листа определяется по конфигурации типа
+		byConfig := models.FBVariableInput{FuncAttr: in.FuncAttr, SignalTag: in.SignalTag}
+		if def, err := byConfig.ResolveDirection(fbConfig.In, fbConfig.Out); err != nil || def != direction {
+			return nil, fmt.Errorf("%w: %s of %s cannot be bound to a signal as %s", ErrInvalid, in.FuncAttr, fb.CdsType, direction)
+		}
+		signals, err := s.signalRepo.GetByTags([]string{in.SignalTag})
+		if err != nil {
+			return nil, err
+		}
+		if len(signals) == 0 {
+			return nil, fmt.Errorf("%w: unknown signal %q", ErrInvalid, in.SignalTag)
+		}
+		signal := signals[0]
+		address, err := models.UpdateAddress(signal, s.cfg().AddressTemplate[signal.SignalType])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		variable.SignalTag = &signal.Tag
+		variable.CdsType = signal.SignalType
+		variable.Address = address
+
+		bindings, err := models.ParseWiring(fb.Bindings)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		bindings[in.FuncAttr] = signal.Tag
+		if err := s.updateBindings(fb, bindings); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.fbRepo.AddVariable(variable); err != nil {
+		return nil, err
+	}
+	if err := s.regenerate(fb); err != nil {
+		return nil, err
+	}
+	return s.GetFunctionBlockDetails(id)
+}
+
+// RemoveFBVariable удаляет привязку входа/выхода ФБ; связь ФБ-ФБ удаляется и из подключения
+// (wiring) в БД и листе FB, отвязка сигнала записывается в колонку signals как "attr=-"
+func (s *SyncService) RemoveFBVariable(id, variableID string) (gin.H, error) {
+	fb, err := s.getFunctionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+	variable, err := s.fbRepo.GetVariable(fb.ID, variableID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: variable %s of function block %s", ErrNotFound, variableID, fb.Tag)
+		}
+		return nil, fmt.Errorf("failed to get FB variable: %w", err)
+	}
+
+	if variable.IsLink() && fb.Wiring != "" {
+		wiring, err := models.ParseWiring(fb.Wiring)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		delete(wiring, variable.FuncAttr)
+		if err := s.updateWiring(fb, wiring); err != nil {
+			return nil, err
+		}
+	}
+	if !variable.IsLink() {
+		if fb.Primary {
+			return nil, fmt.Errorf("%w: signals of primary function block %s are set by its signal", ErrInvalid, fb.Tag)
+		}
+		bindings, err := models.ParseWiring(fb.Bindings)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		bindings[variable.FuncAttr] = models.FBBindingNone
+		if err := s.updateBindings(fb, bindings); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.fbRepo.DeleteVariable(variable); err != nil {
+		return nil, err
+	}
+	if err := s.regenerate(fb); err != nil {
+		return nil, err
+	}
+	return s.GetFunctionBlockDetails(id)
+}
+
+// RegenerateFunctionBlock перегенерирует ST, OMX и OPC одного ФБ
+func (s *SyncService) RegenerateFunctionBlock(id string) (map[string]string, error) {
+	fb, err := s.getFunctionBlock(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.cfg().FunctionBlocks[fb.CdsType]; !ok {
+		return nil, fmt.Errorf("%w: no configuration for cds_type %q", ErrInvalid, fb.CdsType)
+	}
+	generated, err := s.fbRepo.Regenerate(fb.ID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"ST":     generated.Call,
+		"STInit": generated.Init,
+		"OMX":    generated.OMX,
+		"OPC":    generated.OPC,
+	}, nil
+}
+
+// updateWiring сохраняет подключение ФБ в листе FB и в БД
+func (s *SyncService) updateWiring(fb *models.FunctionBlock, wiring map[string]string) error {
+	row := fb.SheetRow()
+	row.Pins = models.FormatWiring(wiring)
+	if err := s.pushFunctionBlock(fb, row); err != nil {
+		return err
+	}
+	return s.fbRepo.UpdateFields(fb, map[string]interface{}{"wiring": row.Pins})
+}
+
+// updateBindings сохраняет ручные привязки сигналов ФБ в листе FB и в БД
+func (s *SyncService) updateBindings(fb *models.FunctionBlock, bindings map[string]string) error {
+	row := fb.SheetRow()
+	row.Signals = models.FormatWiring(bindings)
+	if err := s.pushFunctionBlock(fb, row); err != nil {
+		return err
+	}
+	return s.fbRepo.UpdateFields(fb, map[string]interface{}{"bindings": row.Signals})
+}
+
+// pushFunctionBlock записывает строку ФБ в лист FB до изменения БД: при синхронизации
+// наименование, описание, подключение и привязки сигналов ФБ берутся из листа. Первичных ФБ в листе нет.
+func (s *SyncService) pushFunctionBlock(fb *models.FunctionBlock, row models.SheetFB) error {
+	if fb.Primary {
+		return nil
+	}
+	if s.gsWrite == nil {
+		return fmt.Errorf("sheet write service is not configured")
+	}
+	if err := s.gsWrite.UpsertRow(s.cfg().SpreadsheetID, fbSheetName, "tag", fb.Tag, row); err != nil {
+		return fmt.Errorf("failed to write function block to sheet: %w", err)
+	}
+	return nil
+}
+
+func (s *SyncService) getFunctionBlock(id string) (*models.FunctionBlock, error) {
+	var fb models.FunctionBlock
+	if err := s.fbRepo.GetWithDetails(id, &fb); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: function block %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get function block: %w", err)
+	}
+	return &fb, nil
+}
+
+// regenerate перегенерирует ФБ после изменения; ФБ без конфигурации типа пропускаются
+func (s *SyncService) regenerate(fb *models.FunctionBlock) error {
+	var fresh models.FunctionBlock
+	if err := s.fbRepo.GetWithDetails(fmt.Sprint(fb.ID), &fresh); err != nil {
+		return fmt.Errorf("failed to get function block: %w", err)
+	}
+	if _, ok := s.cfg().FunctionBlocks[fresh.CdsType]; !ok {
+		return nil
+	}
+	_, err := s.fbRepo.Regenerate(fb.ID)
+	return err
+}
@@ -9,11 +9,47 @@ import (
 )
 
 func (s *SyncService) GetFunctionBlockDetails(id string) (gin.H, error) {
-	var fb models.FunctionBlock
-	if err := s.fbRepo.GetWithDetails(id, &fb); err != nil {
-		return nil, fmt.Errorf("failed to get function block details: %w", err)
+	fb, err := s.getFunctionBlock(id)
+	if err != nil {
+		return nil, err
 	}
-	return fb.ToDetailedAPI(), nil
+	details := fb.ToDetailedAPI()
+	if fbConfig, ok := s.cfg().FunctionBlocks[fb.CdsType]; ok && !fb.Primary {
+		report := fb.CheckCompleteness(fbConfig.In, fbConfig.Out, fbConfig.Required, fbConfig.Defaults)
+		details["complete"] = report.Complete()
+		details["missingIn"] = report.MissingIn
+		details["missingOut"] = report.MissingOut
+	}
+	return details, nil
+}
+
+// CheckFBCompleteness возвращает составные ФБ, у которых не подключены
+// обязательные входы/выходы
+func (s *SyncService) CheckFBCompleteness(fbs []*models.FunctionBlock) []models.FBCompleteness {
+	var reports []models.FBCompleteness
+	for _, fb := range fbs {
+		if fb.Primary {
+			continue
+		}
+		fbConfig, ok := s.cfg().FunctionBlocks[fb.CdsType]
+		if !ok || len(fbConfig.Required) == 0 {
+			continue
+		}
+		report := fb.CheckCompleteness(fbConfig.In, fbConfig.Out, fbConfig.Required, fbConfig.Defaults)
+		if !report.Complete() {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// GetFBCompletenessReport формирует отчет о полноте составных ФБ по фильтру
+func (s *SyncService) GetFBCompletenessReport(system, cdsType, node string) ([]models.FBCompleteness, error) {
+	fbs, err := s.fbRepo.GetFiltered(system, cdsType, node)
+	if err != nil {
+		return nil, err
+	}
+	return s.CheckFBCompleteness(fbs), nil
 }
 
 func (s *SyncService) LinkFunctionBlocksToNodes() error {
@@ -49,13 +85,40 @@ func (s *SyncService) SyncFunctionBlocks(signals []models.Signal) error {
 	if err := s.fbRepo.SyncFBFromSignals(signals); err != nil {
 		return fmt.Errorf("failed to sync function blocks: %w", err)
 	}
+	issues, err := s.fbRepo.CheckTagRules(signals)
+	if err != nil {
+		return fmt.Errorf("failed to check tag rules: %w", err)
+	}
+	if len(issues) > 0 {
+		log.Printf("Warning: %d signals do not match FB tag rules, see /api/fb-tag-report", len(issues))
+	}
 	return nil
 }
 
+// GetFBTagReport возвращает сигналы, не привязанные к составным ФБ
+func (s *SyncService) GetFBTagReport() ([]models.FBTagIssue, error) {
+	signals, err := s.signalRepo.GetWithFBType()
+	if err != nil {
+		return nil, err
+	}
+	return s.fbRepo.CheckTagRules(signals)
+}
+
 func (s *SyncService) GetFilteredFunctionBlocks(system, cdsType, node string) ([]*models.FunctionBlock, error) {
 	return s.fbRepo.GetFiltered(system, cdsType, node)
 }
 
+// OrderByDependencies упорядочивает вызовы ФБ так, чтобы ФБ-источники
+// вызывались раньше получателей. При цикле порядок не меняется, цикл возвращается как ошибка
+func (s *SyncService) OrderByDependencies(fbs []*models.FunctionBlock) ([]*models.FunctionBlock, error) {
+	return models.SortByDependencies(fbs)
+}
+
+// GetFBGraph возвращает граф зависимостей ФБ для визуализации
+func (s *SyncService) GetFBGraph(system, cdsType, node string) (*models.FBGraph, error) {
+	return s.fbRepo.GetGraph(system, cdsType, node)
+}
+
 func (s *SyncService) GetAllCDSTypes() ([]string, error) {
 	return s.fbRepo.GetAllCDSTypes()
 }
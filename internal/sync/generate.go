@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// Типы файлов импорта
+var ImportFileTypes = []string{"STDecl", "ST", "STInit", "OMX", "OPC", "InterlocksDecl", "Interlocks"}
+
+// ImportFile - сгенерированный файл импорта
+type ImportFile struct {
+	Content    string
+	Count      int
+	Incomplete []models.FBCompleteness // ФБ с неподключенными обязательными входами/выходами
+	Cycle      string                  // Ошибка упорядочивания вызовов ST (цикл связей ФБ)
+}
+
+// IsInterlocksFile сообщает, что файл формируется из листа блокировок, а не по ФБ
+func IsInterlocksFile(fileType string) bool {
+	return fileType == "InterlocksDecl" || fileType == "Interlocks"
+}
+
+// GenerateImportFile собирает файл импорта fileType из ФБ, отобранных по системе, типу и узлу
+func (s *SyncService) GenerateImportFile(system, cdsType, node, fileType string) (*ImportFile, error) {
+	// Логика блокировок формируется по узлам из листа блокировок, а не по ФБ
+	if IsInterlocksFile(fileType) {
+		decl, body, count, err := s.GenerateInterlocks(system, node)
+		if err != nil {
+			return nil, err
+		}
+		if fileType == "InterlocksDecl" {
+			return &ImportFile{Content: decl, Count: count}, nil
+		}
+		return &ImportFile{Content: body, Count: count}, nil
+	}
+	known := false
+	for _, t := range ImportFileTypes {
+		known = known || t == fileType
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: unknown file type %q, expected one of %s", ErrInvalid, fileType, strings.Join(ImportFileTypes, ", "))
+	}
+
+	fbs, err := s.GetFilteredFunctionBlocks(system, cdsType, node)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ImportFile{Count: len(fbs)}
+	// Вызовы ФБ-источников должны предшествовать вызовам получателей
+	if fileType == "ST" {
+		if fbs, err = s.OrderByDependencies(fbs); err != nil {
+			log.Printf("Failed to order FB calls: %v", err)
+			file.Cycle = err.Error()
+		}
+	}
+
+	var content strings.Builder
+	for _, fb := range fbs {
+		switch fileType {
+		case "STDecl":
+			if fb.Call != "" {
+				content.WriteString(fb.Declaration + "\n\n")
+			}
+		case "ST":
+			if fb.Call != "" {
+				content.WriteString(fb.Call + "\n\n")
+			}
+		case "STInit":
+			if fb.Init != "" {
+				content.WriteString(fb.Init + "\n")
+			}
+		case "OMX":
+			if fb.OMX != "" {
+				content.WriteString(fb.OMX + "\n\n")
+			}
+		case "OPC":
+			if fb.OPC != "" {
+				content.WriteString(fb.OPC + "\n\n")
+			}
+		}
+	}
+	file.Content = content.String()
+	file.Incomplete = s.CheckFBCompleteness(fbs)
+	return file, nil
+}
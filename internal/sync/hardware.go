@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// GetHardwareReport формирует отчет о распределении каналов по крейтам и модулям
+func (s *SyncService) GetHardwareReport(system string) ([]models.HardwareModule, error) {
+	signals, err := s.signalRepo.GetForExport(system, nil)
+	if err != nil {
+		return nil, err
+	}
+	catalogue, err := s.moduleCatalogue()
+	if err != nil {
+		return nil, err
+	}
+	return models.BuildHardwareReport(signals, catalogue), nil
+}
+
+// ExportHardwareToSheet выгружает отчет по оборудованию на лист Hardware
+func (s *SyncService) ExportHardwareToSheet(system string) (int, error) {
+	if s.gsWrite == nil {
+		return 0, fmt.Errorf("sheets write service is not initialized")
+	}
+	report, err := s.GetHardwareReport(system)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []models.SheetHardware
+	for i := range report {
+		rows = append(rows, report[i].SheetRows()...)
+	}
+
+	sheetName := s.cfg().HardwareSheet
+	if sheetName == "" {
+		sheetName = "Hardware"
+	}
+	if err := s.gsWrite.Save(s.cfg().SpreadsheetID, sheetName, rows); err != nil {
+		return 0, fmt.Errorf("failed to save hardware report to sheet: %w", err)
+	}
+	log.Printf("Saved %d hardware rows to sheet %s", len(rows), sheetName)
+	return len(rows), nil
+}
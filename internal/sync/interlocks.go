@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// SyncInterlocks загружает блокировки из листа и сохраняет их со ссылками на ФБ и сигналы
+func (s *SyncService) SyncInterlocks() error {
+	if s.cfg().InterlockSheet == "" {
+		return nil
+	}
+
+	var rows []models.SheetInterlock
+	if err := s.gsRead.Load(s.cfg().SpreadsheetID, s.cfg().InterlockSheet, &rows); err != nil {
+		return fmt.Errorf("failed to load interlocks: %w", err)
+	}
+
+	var fbs []models.FunctionBlock
+	if err := s.fbRepo.GetAll(&fbs); err != nil {
+		return fmt.Errorf("failed to get function blocks: %w", err)
+	}
+	fbByTag := make(map[string]*models.FunctionBlock, len(fbs))
+	for i := range fbs {
+		fbByTag[fbs[i].Tag] = &fbs[i]
+	}
+
+	var causeTags []string
+	for _, row := range rows {
+		causeTags = append(causeTags, strings.TrimSpace(row.Cause))
+	}
+	signals, err := s.signalRepo.GetByTags(causeTags)
+	if err != nil {
+		return err
+	}
+	signalByTag := make(map[string]*models.Signal, len(signals))
+	for i := range signals {
+		signalByTag[signals[i].Tag] = &signals[i]
+	}
+
+	var interlocks []models.Interlock
+	for _, row := range rows {
+		ilk, err := buildInterlock(row, fbByTag, signalByTag)
+		if err != nil {
+			log.Printf("Interlock %s skipped: %v", row.Tag, err)
+			continue
+		}
+		interlocks = append(interlocks, *ilk)
+	}
+
+	if err := s.ilkRepo.ReplaceAll(interlocks); err != nil {
+		return err
+	}
+	log.Printf("Saved %d of %d interlocks", len(interlocks), len(rows))
+	return nil
+}
+
+// buildInterlock разрешает ссылки строки листа блокировок на ФБ и сигналы
+func buildInterlock(row models.SheetInterlock, fbByTag map[string]*models.FunctionBlock, signalByTag map[string]*models.Signal) (*models.Interlock, error) {
+	if row.Tag == "" {
+		return nil, fmt.Errorf("empty id")
+	}
+	priority, err := row.ParsePriority()
+	if err != nil {
+		return nil, err
+	}
+
+	ilk := &models.Interlock{
+		Tag:       row.Tag,
+		Condition: row.Condition,
+		Priority:  priority,
+		Bypass:    row.BypassEnabled(),
+		Comment:   row.Comment,
+	}
+
+	effectTag, effectPin := models.SplitWiringRef(strings.TrimSpace(row.Effect))
+	effect, ok := fbByTag[effectTag]
+	if !ok || effectPin == "" {
+		return nil, fmt.Errorf("effect %q must reference an FB input (FB.pin)", row.Effect)
+	}
+	ilk.EffectFBID, ilk.EffectPin = effect.ID, effectPin
+	ilk.NodeID, ilk.SystemID = effect.NodeID, effect.SystemID
+
+	// Сигнал ищется по полному тэгу (тэги сигналов могут содержать точки), затем ФБ
+	cause := strings.TrimSpace(row.Cause)
+	if signal, ok := signalByTag[cause]; ok {
+		ilk.CauseSignalTag = &signal.Tag
+	} else {
+		causeTag, causePin := models.SplitWiringRef(cause)
+		fb, ok := fbByTag[causeTag]
+		if !ok {
+			return nil, fmt.Errorf("cause %q is neither a signal nor an FB", row.Cause)
+		}
+		ilk.CauseFBID, ilk.CausePin = &fb.ID, causePin
+	}
+	return ilk, nil
+}
+
+// GenerateInterlocks формирует логику блокировок ST по узлам: объявления
+// переменных деблокирования и присваивания отдельно, как STDecl и ST
+func (s *SyncService) GenerateInterlocks(system, node string) (decl, body string, count int, err error) {
+	interlocks, err := s.ilkRepo.GetFiltered(system, node)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	byNode := make(map[string][]models.Interlock)
+	var nodes []string
+	for _, ilk := range interlocks {
+		name := "--"
+		if ilk.Node != nil {
+			name = ilk.Node.Name
+		}
+		if _, ok := byNode[name]; !ok {
+			nodes = append(nodes, name)
+		}
+		byNode[name] = append(byNode[name], ilk)
+	}
+
+	var declContent, bodyContent strings.Builder
+	for _, name := range nodes {
+		nodeDecl, nodeBody := models.GenerateInterlockST(name, byNode[name])
+		if nodeDecl != "" {
+			declContent.WriteString(nodeDecl + "\n")
+		}
+		bodyContent.WriteString(nodeBody + "\n")
+	}
+	return declContent.String(), bodyContent.String(), len(interlocks), nil
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// checkModbusMap проверяет адреса Modbus сигналов: ошибки разбора, дубли и перекрытия регистров
+func (s *SyncService) checkModbusMap(signals []models.Signal) []models.ModbusIssue {
+	registers, issues := models.BuildModbusMap(signals, s.cfg().Modbus.Settings())
+	return append(issues, models.FindModbusConflicts(registers)...)
+}
+
+// GetModbusMap возвращает карту регистров Modbus по системе и узлу с найденными конфликтами
+func (s *SyncService) GetModbusMap(system, node string) ([]models.ModbusRegister, []models.ModbusIssue, error) {
+	signals, err := s.signalRepo.GetForExport(system, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	registers, issues := models.BuildModbusMap(signals, s.cfg().Modbus.Settings())
+	issues = append(issues, models.FindModbusConflicts(registers)...)
+	if node == "" {
+		return registers, issues, nil
+	}
+
+	var nodeRegisters []models.ModbusRegister
+	for _, reg := range registers {
+		if reg.Node == node {
+			nodeRegisters = append(nodeRegisters, reg)
+		}
+	}
+	var nodeIssues []models.ModbusIssue
+	for _, issue := range issues {
+		if issue.Node == node {
+			nodeIssues = append(nodeIssues, issue)
+		}
+	}
+	return nodeRegisters, nodeIssues, nil
+}
+
+// ExportModbus формирует карту регистров Modbus в формате csv или json
+func (s *SyncService) ExportModbus(system, node, format string) (string, int, error) {
+	registers, _, err := s.GetModbusMap(system, node)
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch format {
+	case "json":
+		if registers == nil {
+			registers = []models.ModbusRegister{}
+		}
+		data, err := json.MarshalIndent(registers, "", "  ")
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to marshal modbus map: %w", err)
+		}
+		return string(data), len(registers), nil
+	case "csv":
+		tmpl := s.cfg().Modbus.Format
+		if tmpl.Row == "" {
+			return "", 0, fmt.Errorf("modbus csv format is not configured")
+		}
+		content, err := models.RenderTable(tmpl.Header, tmpl.Row, tmpl.Footer, registers)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to render modbus map: %w", err)
+		}
+		return content, len(registers), nil
+	default:
+		return "", 0, fmt.Errorf("unsupported modbus export format %q", format)
+	}
+}
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	stdsync "sync"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// moduleSheet - типы модулей из листа каталога, прочитанные последней синхронизацией.
+// Лист читается только при синхронизации, типы из конфига берутся из текущего конфига
+type moduleSheet struct {
+	mu    stdsync.RWMutex
+	specs []models.ModuleSpec
+}
+
+func (m *moduleSheet) set(specs []models.ModuleSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.specs = specs
+}
+
+func (m *moduleSheet) get() []models.ModuleSpec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]models.ModuleSpec(nil), m.specs...)
+}
+
+// loadModuleCatalogue читает лист каталога и собирает каталог модулей из конфига и листа
+func (s *SyncService) loadModuleCatalogue() (*models.ModuleCatalogue, error) {
+	var sheetSpecs []models.ModuleSpec
+	if s.cfg().ModuleSheet != "" && s.gsRead != nil {
+		var rows []models.SheetModule
+		if err := s.gsRead.Load(s.cfg().SpreadsheetID, s.cfg().ModuleSheet, &rows); err != nil {
+			return nil, fmt.Errorf("failed to load module catalogue: %w", err)
+		}
+		for _, row := range rows {
+			if row.Code == "" {
+				continue
+			}
+			spec, err := row.ToSpec()
+			if err != nil {
+				log.Printf("Module catalogue: %v", err)
+				continue
+			}
+			sheetSpecs = append(sheetSpecs, spec)
+		}
+	}
+
+	catalogue, err := models.NewModuleCatalogue(append(s.cfg().ModuleSpecs(), sheetSpecs...))
+	if err != nil {
+		return nil, err
+	}
+	s.moduleSheet.set(sheetSpecs)
+	return catalogue, nil
+}
+
+// applyModuleCatalogue привязывает сигналы к типам модулей и возвращает ошибки привязки
+func (s *SyncService) applyModuleCatalogue(signals []models.Signal) ([]models.ModuleIssue, error) {
+	catalogue, err := s.loadModuleCatalogue()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []models.ModuleIssue
+	for i := range signals {
+		issues = append(issues, catalogue.Apply(&signals[i])...)
+	}
+	return issues, nil
+}
+
+// moduleCatalogue возвращает каталог из текущего конфига и листа каталога последней синхронизации
+func (s *SyncService) moduleCatalogue() (*models.ModuleCatalogue, error) {
+	specs := s.cfg().ModuleSpecs()
+	if s.cfg().ModuleSheet != "" {
+		specs = append(specs, s.moduleSheet.get()...)
+	}
+	return models.NewModuleCatalogue(specs)
+}
+
+// GetModuleReport возвращает каталог модулей и ошибки привязки сигналов к нему
+func (s *SyncService) GetModuleReport(system string) ([]models.ModuleSpec, []models.ModuleIssue, error) {
+	catalogue, err := s.moduleCatalogue()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signals, err := s.signalRepo.GetForExport(system, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var issues []models.ModuleIssue
+	for i := range signals {
+		issues = append(issues, catalogue.Apply(&signals[i])...)
+	}
+	return catalogue.Specs(), issues, nil
+}
@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// ListNodeOverrides возвращает ручные назначения узлов
+func (s *SyncService) ListNodeOverrides() ([]models.NodeOverride, error) {
+	return s.overrideRepo.GetAll()
+}
+
+// SaveNodeOverride создает или заменяет ручное назначение узла и сразу применяет его
+// к сигналам из очереди проверки. Возвращает назначение и число перепривязанных сигналов.
+func (s *SyncService) SaveNodeOverride(override models.NodeOverride) (*models.NodeOverride, int, error) {
+	if err := override.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	node, err := s.overrideNode(override)
+	if err != nil {
+		return nil, 0, err
+	}
+	override.ID = 0
+	if err := s.overrideRepo.Upsert(&override); err != nil {
+		return nil, 0, err
+	}
+
+	relinked, err := s.applyOverride(override, node)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &override, relinked, nil
+}
+
+// DeleteNodeOverride удаляет ручное назначение; привязка сигналов изменится при следующей синхронизации
+func (s *SyncService) DeleteNodeOverride(id string) error {
+	deleted, err := s.overrideRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: node override %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetNodeReview возвращает очередь проверки: сопоставления последней синхронизации,
+// для которых создан новый узел или сходство ниже порога
+func (s *SyncService) GetNodeReview() (gin.H, error) {
+	matches, err := s.matchRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	threshold := s.cfg().NodeMatching.ReviewThreshold()
+	queue := []models.NodeMatch{}
+	for _, m := range matches {
+		if m.NeedsReview(threshold) {
+			queue = append(queue, m)
+		}
+	}
+	return gin.H{
+		"threshold": threshold,
+		"matches":   queue,
+		"count":     len(queue),
+	}, nil
+}
+
+// overrideNode находит узел назначения. Имена узлов уникальны только в системе, поэтому узел
+// ищется в системе назначения по node_ref или в системе сигнала назначения по тэгу.
+func (s *SyncService) overrideNode(override models.NodeOverride) (*models.Node, error) {
+	if override.System != "" {
+		system, err := s.systemRepo.GetSystemByName(override.System)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown system %q", ErrInvalid, override.System)
+		}
+		node, err := s.nodeRepo.FindByNameAndSystem(override.Node, system.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown node %q in system %s", ErrInvalid, override.Node, override.System)
+		}
+		return node, nil
+	}
+
+	var systemID *uint
+	if override.Kind == models.OverrideByTag {
+		signals, err := s.signalRepo.GetByTags([]string{override.Key})
+		if err != nil {
+			return nil, err
+		}
+		if len(signals) > 0 {
+			systemID = signals[0].SystemID
+		}
+	}
+	return s.findOverrideNode(override.Node, systemID)
+}
+
+// findOverrideNode ищет узел назначения в системе сигнала, а если его там нет или система
+// неизвестна - по имени во всех системах. Имя, найденное в нескольких системах, отклоняется.
+func (s *SyncService) findOverrideNode(name string, systemID *uint) (*models.Node, error) {
+	if systemID != nil {
+		if node, err := s.nodeRepo.FindByNameAndSystem(name, *systemID); err == nil {
+			return node, nil
+		}
+	}
+	nodes, err := s.nodeRepo.FindAllByName(name)
+	if err != nil {
+		return nil, err
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, fmt.Errorf("%w: unknown node %q", ErrInvalid, name)
+	case 1:
+		return &nodes[0], nil
+	}
+	return nil, fmt.Errorf("%w: node %q exists in %d systems, set the system of the override", ErrInvalid, name, len(nodes))
+}
+
+// applyOverride перепривязывает сигналы последней синхронизации, подпадающие под назначение
+func (s *SyncService) applyOverride(override models.NodeOverride, node *models.Node) (int, error) {
+	matches, err := s.matchRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	idx := models.NewNodeOverrides([]models.NodeOverride{override})
+	var signals []models.Signal
+	var ids []uint
+	for _, m := range matches {
+		if _, ok := idx.Find(m.SignalTag, m.System, m.NodeRef); !ok {
+			continue
+		}
+		signal := models.Signal{NodeID: &node.ID}
+		signal.ID = m.SignalID
+		signals = append(signals, signal)
+		ids = append(ids, m.ID)
+	}
+	if err := s.signalRepo.UpdateSignalNodes(signals); err != nil {
+		return 0, fmt.Errorf("failed to relink signals: %w", err)
+	}
+	if err := s.matchRepo.SetOverride(ids, node.Name); err != nil {
+		return 0, err
+	}
+	return len(signals), nil
+}
+
+// GetNodeMatchReport возвращает сопоставления узлов последней синхронизации, кроме точных,
+// с текстом узла из листа, выбранным узлом и сходством; строки сгруппированы по системе и узлу
+func (s *SyncService) GetNodeMatchReport(system string) ([]models.NodeMatch, error) {
+	return s.matchRepo.GetReport(system)
+}
+
+// ExportNodeMatchReport выгружает отчет о сопоставлении узлов на лист node_matching.report_sheet
+func (s *SyncService) ExportNodeMatchReport(system string) (int, error) {
+	if s.gsWrite == nil {
+		return 0, fmt.Errorf("sheets write service is not initialized")
+	}
+	matches, err := s.GetNodeMatchReport(system)
+	if err != nil {
+		return 0, err
+	}
+	threshold := s.cfg().NodeMatching.ReviewThreshold()
+	var rows []models.SheetNodeMatch
+	for _, m := range matches {
+		rows = append(rows, m.SheetRow(threshold))
+	}
+
+	sheetName := s.cfg().NodeMatching.ReportSheet
+	if sheetName == "" {
+		sheetName = "NodeMatches"
+	}
+	if err := s.gsWrite.Save(s.cfg().SpreadsheetID, sheetName, rows); err != nil {
+		return 0, fmt.Errorf("failed to save node match report to sheet: %w", err)
+	}
+	log.Printf("Saved %d node matches to sheet %s", len(rows), sheetName)
+	return len(rows), nil
+}
@@ -3,10 +3,10 @@ package sync
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/pkg/models"
 )
 
@@ -52,7 +52,7 @@ func (s *SyncService) syncNodes(nodes []models.Node) error {
 func (s *SyncService) loadNodesFromSheets(ctx context.Context) ([]models.Node, error) {
 	var sheetNodes []models.SheetNode
 
-	if err := s.gsRead.Load(config.Cfg.SpreadsheetID, config.Cfg.NodeSheet, &sheetNodes); err != nil {
+	if err := s.gsRead.Load(s.cfg().SpreadsheetID, s.cfg().NodeSheet, &sheetNodes); err != nil {
 		return nil, fmt.Errorf("failed to load nodes: %w", err)
 	}
 
@@ -83,20 +83,33 @@ func (s *SyncService) loadNodesFromSheets(ctx context.Context) ([]models.Node, e
 	return nodes, nil
 }
 
-func (s *SyncService) findBestNodeMatch(nodeName string, systemID uint) (*models.Node, error) {
+// findBestNodeMatch ищет узел по точному имени, затем наиболее похожий в системе;
+// если похожих нет, создает новый узел. Если сходство лучшего узла ниже
+// node_matching.min_score, узел не выбирается (nil) и совпадение помечается rejected.
+func (s *SyncService) findBestNodeMatch(nodeName string, systemID uint) (*models.Node, models.NodeMatch, error) {
+	match := models.NodeMatch{NodeRef: nodeName}
 	if node, err := s.nodeRepo.FindByName(nodeName); err == nil {
-		return node, nil
+		match.Node, match.Method, match.Score = node.Name, models.NodeMatchExact, 1
+		return node, match, nil
 	}
 	if nodeName == "" {
 		nodeName = "Общее"
 	}
-	nodes, err := s.nodeRepo.FindSimilarInSystem(nodeName, systemID)
+	candidates, err := s.similarNodes(nodeName, systemID)
 	if err != nil {
-		return nil, err
+		return nil, match, err
 	}
 
-	if len(nodes) > 0 {
-		return &nodes[0], nil
+	if len(candidates) > 0 {
+		best := candidates[0]
+		if best.Score < s.cfg().NodeMatching.MinScore {
+			match.Method, match.Score, match.Candidates = models.NodeMatchRejected, best.Score, candidates
+			return nil, match, nil
+		}
+		match.Node, match.Method, match.Score, match.Candidates = best.Name, models.NodeMatchFuzzy, best.Score, candidates
+		node := &models.Node{Name: best.Name}
+		node.ID = best.ID
+		return node, match, nil
 	}
 
 	newNode := &models.Node{
@@ -104,11 +117,64 @@ func (s *SyncService) findBestNodeMatch(nodeName string, systemID uint) (*models
 		SystemID: &systemID,
 	}
 	if err := s.nodeRepo.Create(newNode); err != nil {
+		return nil, match, err
+	}
+	match.Node, match.Method = newNode.Name, models.NodeMatchCreated
+
+	return newNode, match, nil
+}
+
+// similarNodes возвращает до трех узлов системы, наиболее похожих на имя,
+// по стратегии node_matching.strategy
+func (s *SyncService) similarNodes(name string, systemID uint) ([]models.NodeCandidate, error) {
+	matcher, err := s.cfg().NodeMatching.Matcher()
+	if err != nil {
+		return nil, err
+	}
+	if matcher == nil {
+		return s.nodeRepo.FindSimilarInSystem(name, systemID)
+	}
+	nodes, err := s.nodeRepo.GetInSystem(systemID)
+	if err != nil {
 		return nil, err
 	}
+	return models.RankNodes(matcher, name, nodes, 3), nil
+}
+
+// resolveNode определяет узел сигнала: сначала ручное назначение, затем findBestNodeMatch.
+// Возвращает nil, если узел не задан ни в листе, ни назначением, или совпадение отклонено.
+func (s *SyncService) resolveNode(overrides *models.NodeOverrides, tag, system string, systemID uint, nodeRef string) (*models.Node, models.NodeMatch, error) {
+	if o, ok := overrides.Find(tag, system, nodeRef); ok {
+		node, err := s.findOverrideNode(o.Node, &systemID)
+		if err == nil {
+			return node, models.NodeMatch{
+				SignalTag: tag,
+				System:    system,
+				NodeRef:   nodeRef,
+				Node:      node.Name,
+				Method:    models.NodeMatchOverride,
+				Score:     1,
+			}, nil
+		}
+		log.Printf("Signal %s: override node: %v, using fuzzy matching", tag, err)
+	}
+	if nodeRef == "" {
+		return nil, models.NodeMatch{}, nil
+	}
+	node, match, err := s.findBestNodeMatch(nodeRef, systemID)
+	match.SignalTag, match.System = tag, system
+	return node, match, err
+}
 
-	return newNode, nil
+// nodeOverrides загружает ручные назначения узлов
+func (s *SyncService) nodeOverrides() (*models.NodeOverrides, error) {
+	overrides, err := s.overrideRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewNodeOverrides(overrides), nil
 }
+
 func (s *SyncService) GetNodesBySystem(system string) ([]*models.Node, error) {
 	return s.nodeRepo.GetNodesBySystem(system)
 }
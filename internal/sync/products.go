@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/pkg/models"
 )
 
@@ -20,7 +19,7 @@ func (s *SyncService) GetProductDetails(id string) (gin.H, error) {
 func (s *SyncService) loadProductsFromSheets(ctx context.Context) ([]models.Product, error) {
 	var sheetProducts []models.SheetProduct
 
-	if err := s.gsRead.Load(config.Cfg.SpreadsheetID, config.Cfg.ProductSheet, &sheetProducts); err != nil {
+	if err := s.gsRead.Load(s.cfg().SpreadsheetID, s.cfg().ProductSheet, &sheetProducts); err != nil {
 		return nil, fmt.Errorf("failed to load products: %w", err)
 	}
 
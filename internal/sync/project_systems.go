@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/pkg/models"
 )
 
@@ -14,7 +13,7 @@ func (s *SyncService) SyncProjectsAndSystems() error {
 		return fmt.Errorf("failed to sync projects: %w", err)
 	}
 
-	for _, sysConfig := range config.Cfg.Systems {
+	for _, sysConfig := range s.cfg().Systems {
 		if _, err := s.systemRepo.LinkSystemToProject(sysConfig, project.ID); err != nil {
 			return fmt.Errorf("failed to sync system %s: %w", sysConfig, err)
 		}
@@ -0,0 +1,16 @@
+package sync
+
+import (
+	"fmt"
+
+	"github.com/mejzh77/astragen/pkg/models"
+)
+
+// Search ищет сигналы и ФБ по тексту с фильтрами по фасетам
+func (s *SyncService) Search(query models.SearchQuery) (*models.SearchResult, error) {
+	query.Normalize()
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return s.searchRepo.Search(query)
+}
@@ -6,40 +6,62 @@ import (
 	"github.com/mejzh77/astragen/pkg/models"
 	"log"
 	"os"
-	"reflect"
 	"strings"
+	stdsync "sync"
 
 	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/internal/gsheets"
 	"github.com/mejzh77/astragen/internal/repository"
-	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 )
 
+// fbSheetName - лист ФБ: программные ФБ и подключения входов/выходов
+const fbSheetName = "FB"
+
 type SyncService struct {
-	gsRead      *gsheets.Service
-	gsWrite     *gsheets.WriteService
-	projectRepo *repository.ProjectRepository
-	signalRepo  *repository.SignalRepository
-	fbRepo      *repository.FunctionBlockRepository
-	nodeRepo    *repository.NodeRepository
-	productRepo *repository.ProductRepository
-	systemRepo  *repository.SystemRepository
+	gsRead       *gsheets.Service
+	gsWrite      *gsheets.WriteService
+	projectRepo  *repository.ProjectRepository
+	signalRepo   *repository.SignalRepository
+	fbRepo       *repository.FunctionBlockRepository
+	nodeRepo     *repository.NodeRepository
+	productRepo  *repository.ProductRepository
+	systemRepo   *repository.SystemRepository
+	ilkRepo      *repository.InterlockRepository
+	searchRepo   *repository.SearchRepository
+	overrideRepo *repository.NodeOverrideRepository
+	versionRepo  *repository.ConfigVersionRepository
+	matchRepo    *repository.NodeMatchRepository
+	credentials  string        // Файл ключа сервисного аккаунта Google
+	runMu        stdsync.Mutex // Одна полная синхронизация за раз
+	versionMu    stdsync.Mutex // Сохранение конфига и запись его версии
+	moduleSheet  *moduleSheet  // Типы модулей из листа каталога, общие с копиями сервиса
+
+	// snapshot - конфиг синхронизации в копии сервиса из withConfig: изменения
+	// и перезагрузка конфига применяются со следующей синхронизации; nil - текущий конфиг
+	snapshot *config.AppConfig
 }
 
 func NewSyncService(
 	gsheets *gsheets.Service,
 	db *gorm.DB,
 ) *SyncService {
-	return &SyncService{
-		gsRead:      gsheets,
-		projectRepo: repository.NewProjectRepository(db),
-		signalRepo:  repository.NewSignalRepository(db),
-		fbRepo:      repository.NewFunctionBlockRepository(db),
-		nodeRepo:    repository.NewNodeRepository(db),
-		productRepo: repository.NewProductRepository(db),
-		systemRepo:  repository.NewSystemRepository(db),
-	}
+	s := &SyncService{
+		gsRead:       gsheets,
+		projectRepo:  repository.NewProjectRepository(db),
+		signalRepo:   repository.NewSignalRepository(db),
+		fbRepo:       repository.NewFunctionBlockRepository(db),
+		nodeRepo:     repository.NewNodeRepository(db),
+		productRepo:  repository.NewProductRepository(db),
+		systemRepo:   repository.NewSystemRepository(db),
+		ilkRepo:      repository.NewInterlockRepository(db),
+		searchRepo:   repository.NewSearchRepository(db),
+		overrideRepo: repository.NewNodeOverrideRepository(db),
+		versionRepo:  repository.NewConfigVersionRepository(db),
+		matchRepo:    repository.NewNodeMatchRepository(db),
+		moduleSheet:  &moduleSheet{},
+	}
+	return s
 }
 
 func (s *SyncService) SetWriteService(sheetsService *gsheets.WriteService) {
@@ -50,25 +72,92 @@ func (s *SyncService) SetReadService(sheetsService *gsheets.Service) {
 	s.gsRead = sheetsService
 }
 
+// SetCredentialsPath задает файл ключа Google API; приоритетнее credentials и credentials_file конфига
+func (s *SyncService) SetCredentialsPath(path string) {
+	s.credentials = path
+}
+
+// readCredentials возвращает ключ Google API: файл SetCredentialsPath, содержимое
+// credentials из конфига, файл credentials_file или credentials.json
+func (s *SyncService) readCredentials() ([]byte, error) {
+	path := s.credentials
+	if cfg := s.cfg(); path == "" && cfg != nil {
+		if cfg.Credentials != "" {
+			return []byte(cfg.Credentials), nil
+		}
+		path = cfg.CredentialsFile
+	}
+	if path == "" {
+		path = "credentials.json"
+	}
+	creds, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	return creds, nil
+}
+
+// cfg возвращает конфиг: снимок синхронизации или текущий
+func (s *SyncService) cfg() *config.AppConfig {
+	if s.snapshot != nil {
+		return s.snapshot
+	}
+	return config.Get()
+}
+
+// withConfig возвращает копию сервиса для одной синхронизации: репозитории общие,
+// конфиг - снимок cfg. Обработчики API продолжают читать текущий конфиг
+func (s *SyncService) withConfig(cfg *config.AppConfig) *SyncService {
+	return &SyncService{
+		gsRead:       s.gsRead,
+		gsWrite:      s.gsWrite,
+		projectRepo:  s.projectRepo,
+		signalRepo:   s.signalRepo,
+		fbRepo:       s.fbRepo.WithConfig(cfg),
+		nodeRepo:     s.nodeRepo,
+		productRepo:  s.productRepo,
+		systemRepo:   s.systemRepo,
+		ilkRepo:      s.ilkRepo,
+		searchRepo:   s.searchRepo,
+		overrideRepo: s.overrideRepo,
+		versionRepo:  s.versionRepo,
+		matchRepo:    s.matchRepo,
+		credentials:  s.credentials,
+		moduleSheet:  s.moduleSheet,
+		snapshot:     cfg,
+	}
+}
+
 func (s *SyncService) RunFullSync(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	run := s.withConfig(config.Get())
+
 	log.Println("Initializing services...")
-	creds, err := os.ReadFile("credentials.json")
+	creds, err := run.readCredentials()
 	if err != nil {
-		log.Fatalf("Failed to read credentials file: %v", err)
+		return err
 	}
 
 	sheetsService, err := gsheets.NewService(ctx, creds)
 	if err != nil {
-		log.Fatalf("Failed to create Google Sheets service: %v", err)
+		return fmt.Errorf("failed to create Google Sheets service: %w", err)
 	}
 	// 4. Полная синхронизация с логированием
 	log.Println("Starting full sync process...")
 
 	s.SetReadService(sheetsService)
+	run.SetReadService(sheetsService)
 	writeService, err := gsheets.NewWriteService(ctx, creds)
 	if err == nil {
 		s.SetWriteService(writeService)
+		run.SetWriteService(writeService)
 	}
+	return run.runFullSync(ctx)
+}
+
+// runFullSync выполняет шаги синхронизации со снимком конфига копии сервиса
+func (s *SyncService) runFullSync(ctx context.Context) error {
 	// 4.2. Синхронизация сигналов
 	if err := s.SyncProjectsAndSystems(); err != nil {
 		return fmt.Errorf("failed to sync projects and systems: %w", err)
@@ -94,9 +183,12 @@ func (s *SyncService) RunFullSync(ctx context.Context) error {
 	if err := s.LinkFunctionBlocksToNodes(); err != nil {
 		return fmt.Errorf("failed to link function blocks: %w", err)
 	}
-	if err := s.SyncFunctionBlocksWithSheet(config.Cfg.SpreadsheetID, "FB"); err != nil {
+	if err := s.SyncFunctionBlocksWithSheet(s.cfg().SpreadsheetID, fbSheetName); err != nil {
 		return fmt.Errorf("failed to sync function blocks: %w", err)
 	}
+	if err := s.SyncInterlocks(); err != nil {
+		return fmt.Errorf("failed to sync interlocks: %w", err)
+	}
 	return nil
 }
 
@@ -128,52 +220,50 @@ func (s *SyncService) SyncFunctionBlocksWithSheet(spreadsheetID, sheetName strin
 
 	// 4. Синхронизация: Sheet -> DB
 	var sheetToDBUpdates []models.SheetFB
+	var softwareTags []string
 	for tag, sheetFB := range sheetFBMap {
-		if dbFB, exists := dbFBMap[tag]; exists {
-			// Обновляем существующую запись в БД, если есть изменения
-			if s.fbNeedsUpdate(dbFB, sheetFB) {
-				sheetToDBUpdates = append(sheetToDBUpdates, sheetFB)
-			}
-		} else {
-			// Добавляем новую запись в БД
+		dbFB, exists := dbFBMap[tag]
+		if isSoftwareFB(sheetFB, dbFB, exists) {
+			softwareTags = append(softwareTags, tag)
+		}
+		if !exists || s.fbNeedsUpdate(dbFB, sheetFB) {
 			sheetToDBUpdates = append(sheetToDBUpdates, sheetFB)
 		}
 	}
 
 	if len(sheetToDBUpdates) > 0 {
-		if err := s.updateDBFunctionBlocks(sheetToDBUpdates); err != nil {
+		if err := s.updateDBFunctionBlocks(sheetToDBUpdates, dbFBMap); err != nil {
 			return fmt.Errorf("failed to update db function blocks: %w", err)
 		}
 		log.Printf("Updated %d function blocks in DB from sheet", len(sheetToDBUpdates))
 	}
 
+	// Программные ФБ, удаленные из листа, удаляются из БД
+	deleted, err := s.fbRepo.DeleteSoftwareExcept(softwareTags)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d software function blocks missing in sheet", deleted)
+	}
+
+	if err := s.fbRepo.GenerateWiredFBs(); err != nil {
+		return fmt.Errorf("failed to generate wired function blocks: %w", err)
+	}
+
 	// 5. Синхронизация: DB -> Sheet (полная перезапись листа)
-	if len(dbFBMap) > 0 {
+	dbFBs = nil
+	if err := s.fbRepo.GetAll(&dbFBs); err != nil {
+		return fmt.Errorf("failed to get function blocks from db: %w", err)
+	}
+	if len(dbFBs) > 0 {
 		// Преобразуем все функциональные блоки в формат SheetFB
 		var allSheetFBs []models.SheetFB
-		for _, fb := range dbFBMap {
+		for _, fb := range dbFBs {
 			if fb.Primary {
 				continue
 			}
-			var sys, descr, name string
-			if fb.System != nil {
-				sys = fb.System.Name
-			} else {
-				sys = "--"
-			}
-			if fb.Name != sheetFBMap[fb.Tag].Name {
-				name = sheetFBMap[fb.Tag].Name
-			}
-			if fb.Description != sheetFBMap[fb.Tag].Description {
-				descr = sheetFBMap[fb.Tag].Description
-			}
-			allSheetFBs = append(allSheetFBs, models.SheetFB{
-				Name:        name,
-				Tag:         fb.Tag,
-				Description: descr,
-				CdsType:     fb.CdsType,
-				System:      sys,
-			})
+			allSheetFBs = append(allSheetFBs, fb.SheetRow())
 		}
 
 		// Используем метод Save для полной перезаписи листа
@@ -186,266 +276,98 @@ func (s *SyncService) SyncFunctionBlocksWithSheet(spreadsheetID, sheetName strin
 	return nil
 }
 
+// isSoftwareFB определяет, объявлен ли ФБ в листе FB (без сигналов). Программный ФБ требует
+// явного origin=sheet: в старых листах колонки origin нет, и строки без нее, которых нет в БД, -
+// устаревшие ФБ из сигналов.
+func isSoftwareFB(sheetFB models.SheetFB, dbFB models.FunctionBlock, exists bool) bool {
+	if exists {
+		return dbFB.Software
+	}
+	return sheetFB.Origin == models.FBOriginSheet && sheetFB.CdsType != ""
+}
+
 // updateDBFunctionBlocks обновляет функциональные блоки в БД
-func (s *SyncService) updateDBFunctionBlocks(sheetFBs []models.SheetFB) error {
+func (s *SyncService) updateDBFunctionBlocks(sheetFBs []models.SheetFB, dbFBMap map[string]models.FunctionBlock) error {
 	for _, sheetFB := range sheetFBs {
-		if sheetFB.Name == "" {
+		if sheetFB.Tag == "" {
+			continue
+		}
+		wiring, err := models.ParseWiring(sheetFB.Pins)
+		if err != nil {
+			log.Printf("FB %s: %v", sheetFB.Tag, err)
 			continue
 		}
+		bindings, err := models.ParseWiring(sheetFB.Signals)
+		if err != nil {
+			log.Printf("FB %s: %v", sheetFB.Tag, err)
+			continue
+		}
+
+		dbFB, exists := dbFBMap[sheetFB.Tag]
+		if !isSoftwareFB(sheetFB, dbFB, exists) {
+			if !exists {
+				continue
+			}
+			if err := s.fbRepo.UpdateSheetFields(sheetFB.Tag, sheetFB.Name, sheetFB.Description,
+				models.FormatWiring(wiring), models.FormatWiring(bindings)); err != nil {
+				return fmt.Errorf("failed to update function block %s: %w", sheetFB.Tag, err)
+			}
+			continue
+		}
+
 		fb := models.FunctionBlock{
 			Tag:         sheetFB.Tag,
+			CdsType:     sheetFB.CdsType,
 			Name:        sheetFB.Name,
 			Description: sheetFB.Description,
+			Wiring:      models.FormatWiring(wiring),
+			Bindings:    models.FormatWiring(bindings),
+			NodeRef:     sheetFB.Node,
 		}
-
-		if err := s.fbRepo.Upsert(fb); err != nil {
-			return fmt.Errorf("failed to upsert function block %s: %w", sheetFB.Tag, err)
+		if _, ok := s.cfg().FunctionBlocks[fb.CdsType]; !ok {
+			log.Printf("FB %s: cds_type %q is not configured", fb.Tag, fb.CdsType)
 		}
-	}
-	return nil
-}
-
-// fbNeedsUpdate проверяет, нужно ли обновлять запись в БД
-func (s *SyncService) fbNeedsUpdate(dbFB models.FunctionBlock, sheetFB models.SheetFB) bool {
-	return dbFB.Name != sheetFB.Name || dbFB.Description != sheetFB.Description
-}
-
-// Добавьте эти методы в SyncService
-func (s *SyncService) GetConfig() (map[string]interface{}, error) {
-	// Преобразуем структуру конфига в map для удобства работы с фронтендом
-	cfg := config.Cfg
-	if cfg == nil {
-		return nil, fmt.Errorf("config not loaded")
-	}
-
-	// Конвертируем структуру в map
-	configMap := make(map[string]interface{})
-
-	// Database
-	configMap["db"] = map[string]interface{}{
-		"host":     cfg.DB.Host,
-		"port":     cfg.DB.Port,
-		"user":     cfg.DB.User,
-		"password": cfg.DB.Password,
-		"name":     cfg.DB.Name,
-		"ssl_mode": cfg.DB.SSLMode,
-	}
-
-	// Spreadsheet
-	configMap["spreadsheet_id"] = cfg.SpreadsheetID
-	configMap["nodesheet"] = cfg.NodeSheet
-	configMap["productsheet"] = cfg.ProductSheet
-	configMap["update"] = cfg.Update
-
-	// Systems
-	configMap["systems"] = cfg.Systems
-	addresses := make(map[string]interface{})
-	for t, s := range cfg.AddressTemplate {
-		addresses[t] = s
-	}
-	configMap["signal_addresses"] = addresses
-	// Function blocks
-	fbs := make(map[string]interface{})
-	for name, fb := range cfg.FunctionBlocks {
-		fbs[name] = map[string]interface{}{
-			"st_template": fb.Template,
-			"in":          fb.In,
-			"out":         fb.Out,
-			"omx": map[string]interface{}{
-				"template":   fb.OMX.Template,
-				"attributes": fb.OMX.Attributes,
-			},
-			"opc": map[string]interface{}{
-				"items": fb.OPC.Items,
-			},
-		}
-	}
-	configMap["function_blocks"] = fbs
-
-	return configMap, nil
-}
-
-func (s *SyncService) UpdateConfig(updates map[string]interface{}) error {
-	cfg := config.Cfg
-	if cfg == nil {
-		return fmt.Errorf("config not loaded")
-	}
-
-	// Обновляем простые поля
-	if spreadsheetID, ok := updates["spreadsheet_id"].(string); ok {
-		cfg.SpreadsheetID = spreadsheetID
-	}
-	if nodesheet, ok := updates["nodesheet"].(string); ok {
-		cfg.NodeSheet = nodesheet
-	}
-	if productsheet, ok := updates["productsheet"].(string); ok {
-		cfg.ProductSheet = productsheet
-	}
-	if update, ok := updates["update"].(bool); ok {
-		cfg.Update = update
-	}
-
-	// Обработка систем (список)
-	if systems, ok := updates["systems"].([]interface{}); ok {
-		cfg.Systems = make([]string, 0, len(systems))
-		for _, sys := range systems {
-			if s, ok := sys.(string); ok {
-				cfg.Systems = append(cfg.Systems, s)
+		if sheetFB.System != "" && sheetFB.System != "--" {
+			system, err := s.systemRepo.GetSystemByName(sheetFB.System)
+			if err != nil {
+				log.Printf("FB %s: system %q not found", fb.Tag, sheetFB.System)
+			} else {
+				fb.SystemID = &system.ID
 			}
 		}
-	}
-	if addresses, ok := updates["signal_addresses"].(map[string]interface{}); ok {
-		cfg.AddressTemplate = make(map[string]string)
-		for key, addr := range addresses {
-			if s, ok := addr.(string); ok {
-				cfg.AddressTemplate[key] = s
+		if sheetFB.Node != "" {
+			node, err := s.nodeRepo.FindByName(sheetFB.Node)
+			if err != nil {
+				log.Printf("FB %s: node %q not found", fb.Tag, sheetFB.Node)
+			} else {
+				fb.NodeID = &node.ID
 			}
 		}
-	}
-	// Инициализация DB, если она nil
-	if cfg.DB == nil {
-		cfg.DB = &config.DatabaseConfig{
-			Host:     "",
-			Port:     "",
-			User:     "",
-			Password: "",
-			Name:     "",
-			SSLMode:  "disable",
-		}
-	}
-
-	// Обработка функциональных блоков
-	if fbs, ok := updates["function_blocks"].(map[string]interface{}); ok {
-		if cfg.FunctionBlocks == nil {
-			cfg.FunctionBlocks = make(map[string]config.FBConfig)
-		}
 
-		for name, fbData := range fbs {
-			if fb, ok := fbData.(map[string]interface{}); ok {
-				currentFB := cfg.FunctionBlocks[name]
-
-				// Обновляем шаблон
-				if template, ok := fb["st_template"].(string); ok {
-					currentFB.Template = template
-				}
-
-				// Обработка входов (in)
-				if in, ok := fb["in"].(map[string]interface{}); ok {
-					if currentFB.In == nil {
-						currentFB.In = make(map[string]string)
-					}
-					syncMap(currentFB.In, in)
-				} else {
-					// Если в обновлении нет in, очищаем существующие значения
-					currentFB.In = make(map[string]string)
-				}
-
-				// Обработка выходов (out)
-				if out, ok := fb["out"].(map[string]interface{}); ok {
-					if currentFB.Out == nil {
-						currentFB.Out = make(map[string]string)
-					}
-					syncMap(currentFB.Out, out)
-				} else {
-					currentFB.Out = make(map[string]string)
-				}
-
-				// Обработка OMX
-				if omx, ok := fb["omx"].(map[string]interface{}); ok {
-					if template, ok := omx["template"].(string); ok {
-						currentFB.OMX.Template = template
-					}
-					if attrs, ok := omx["attributes"].(map[string]interface{}); ok {
-						if currentFB.OMX.Attributes == nil {
-							currentFB.OMX.Attributes = make(map[string]string)
-						}
-						syncMap(currentFB.OMX.Attributes, attrs)
-					} else {
-						currentFB.OMX.Attributes = make(map[string]string)
-					}
-				}
-
-				// Обработка OPC
-				if opc, ok := fb["opc"].(map[string]interface{}); ok {
-					if items, ok := opc["items"].([]interface{}); ok {
-						currentFB.OPC.Items = make([]string, 0, len(items))
-						for _, item := range items {
-							if s, ok := item.(string); ok {
-								currentFB.OPC.Items = append(currentFB.OPC.Items, s)
-							}
-						}
-					} else {
-						currentFB.OPC.Items = nil
-					}
-				}
-
-				cfg.FunctionBlocks[name] = currentFB
-			}
+		if err := s.fbRepo.UpsertSoftware(&fb); err != nil {
+			return fmt.Errorf("failed to upsert function block %s: %w", sheetFB.Tag, err)
 		}
 	}
-
-	// Сохраняем конфиг
-	data, err := yaml.Marshal(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
-
-	configPath := "config.yml" // или путь из переменных окружения/флагов
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
-
 	return nil
 }
 
-// Вспомогательная функция для синхронизации карт
-func syncMap(dest map[string]string, src map[string]interface{}) {
-	// Сначала удаляем ключи, которых нет в src
-	for key := range dest {
-		if _, exists := src[key]; !exists {
-			delete(dest, key)
-		}
+// fbNeedsUpdate проверяет, нужно ли обновлять запись в БД
+func (s *SyncService) fbNeedsUpdate(dbFB models.FunctionBlock, sheetFB models.SheetFB) bool {
+	wiring, _ := models.ParseWiring(sheetFB.Pins)
+	bindings, _ := models.ParseWiring(sheetFB.Signals)
+	if dbFB.Name != sheetFB.Name || dbFB.Description != sheetFB.Description ||
+		dbFB.Wiring != models.FormatWiring(wiring) || dbFB.Bindings != models.FormatWiring(bindings) {
+		return true
 	}
-
-	// Затем добавляем/обновляем значения
-	for key, val := range src {
-		if strVal, ok := val.(string); ok {
-			dest[key] = strVal
-		}
+	if !dbFB.Software {
+		return false
 	}
-}
-
-// Вспомогательная функция для обновления структур через reflection
-func updateMapValues(src map[string]interface{}, dest interface{}) {
-	val := reflect.ValueOf(dest).Elem()
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-
-		// Получаем тег yaml для имени поля
-		yamlTag := fieldType.Tag.Get("yaml")
-		if yamlTag == "" {
-			yamlTag = strings.ToLower(fieldType.Name)
-		}
-
-		if srcVal, ok := src[yamlTag]; ok {
-			switch field.Kind() {
-			case reflect.String:
-				if strVal, ok := srcVal.(string); ok {
-					field.SetString(strVal)
-				}
-			case reflect.Bool:
-				if boolVal, ok := srcVal.(bool); ok {
-					field.SetBool(boolVal)
-				}
-			case reflect.Map:
-				if srcMap, ok := srcVal.(map[string]interface{}); ok {
-					destMap := field.Interface().(map[string]string)
-					syncMap(destMap, srcMap)
-				}
-			}
-		}
+	var sys, node string
+	if dbFB.System != nil {
+		sys = dbFB.System.Name
+	}
+	if dbFB.Node != nil {
+		node = dbFB.Node.Name
 	}
+	return dbFB.CdsType != sheetFB.CdsType || sys != strings.TrimPrefix(sheetFB.System, "--") || node != sheetFB.Node
 }
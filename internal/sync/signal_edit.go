@@ -0,0 +1,227 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mejzh77/astragen/pkg/models"
+	"gorm.io/gorm"
+)
+
+// ListSignals возвращает страницу сигналов по фильтру и общее количество
+func (s *SyncService) ListSignals(filter models.SignalFilter) ([]gin.H, int64, error) {
+	if filter.PageSize > 0 && filter.Page < 1 {
+		filter.Page = 1
+	}
+	signals, total, err := s.signalRepo.GetFiltered(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]gin.H, 0, len(signals))
+	for i := range signals {
+		result = append(result, signals[i].ToDetailedAPI())
+	}
+	return result, total, nil
+}
+
+// GetSignalInput возвращает поля сигнала в виде запроса API (основа для частичного обновления)
+func (s *SyncService) GetSignalInput(id string) (*models.SignalInput, error) {
+	signal, err := s.getSignal(id)
+	if err != nil {
+		return nil, err
+	}
+	in := signal.ToInput()
+	return &in, nil
+}
+
+// CreateSignal проверяет и сохраняет новый сигнал и генерирует его ФБ так же, как синхронизация;
+// при push добавляет строку на лист типа сигнала. Без push сигнал есть только в БД и пропадет
+// при следующей синхронизации. Возвращает сигнал и предупреждения (например, по каталогу модулей).
+func (s *SyncService) CreateSignal(in models.SignalInput, push bool) (gin.H, []string, error) {
+	signal, warnings, err := s.resolveSignal(&in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing, _ := s.signalRepo.GetByTags([]string{signal.Tag}); len(existing) > 0 {
+		return nil, nil, fmt.Errorf("%w: signal %s already exists", ErrInvalid, signal.Tag)
+	}
+
+	if push {
+		if err := s.pushSignal(&in, in.Tag); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.signalRepo.Create(signal); err != nil {
+		return nil, nil, err
+	}
+	if err := s.SyncFunctionBlocks([]models.Signal{*signal}); err != nil {
+		return nil, nil, err
+	}
+	return signal.ToDetailedAPI(), dbOnlyWarning(warnings, push), nil
+}
+
+// UpdateSignal заменяет поля сигнала и перегенерирует его ФБ; тэг и тип сигнала не меняются.
+// При push обновляет строку листа типа сигнала, иначе изменение действует до следующей синхронизации.
+func (s *SyncService) UpdateSignal(id string, in models.SignalInput, push bool) (gin.H, []string, error) {
+	existing, err := s.getSignal(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	signal, warnings, err := s.resolveSignal(&in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signal.Tag != existing.Tag {
+		return nil, nil, fmt.Errorf("%w: tag cannot be changed", ErrInvalid)
+	}
+	if signal.SignalType != existing.SignalType {
+		return nil, nil, fmt.Errorf("%w: signal type cannot be changed", ErrInvalid)
+	}
+	signal.ID = existing.ID
+	signal.CreatedAt = existing.CreatedAt
+
+	if push {
+		if err := s.pushSignal(&in, existing.Tag); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.signalRepo.Update(signal); err != nil {
+		return nil, nil, err
+	}
+	if err := s.SyncFunctionBlocks([]models.Signal{*signal}); err != nil {
+		return nil, nil, err
+	}
+	return signal.ToDetailedAPI(), dbOnlyWarning(warnings, push), nil
+}
+
+// DeleteSignal удаляет сигнал и его первичный ФБ, если на сигнал не ссылаются ФБ и блокировки;
+// при push удаляет строку с листа типа сигнала, иначе сигнал вернется при следующей синхронизации
+func (s *SyncService) DeleteSignal(id string, push bool) error {
+	signal, err := s.getSignal(id)
+	if err != nil {
+		return err
+	}
+	refs, err := s.signalRepo.GetReferences(signal.Tag)
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		return fmt.Errorf("%w: signal %s is used by %s", ErrInvalid, signal.Tag, strings.Join(refs, ", "))
+	}
+
+	if push {
+		sheet, err := s.signalSheet(signal.SignalType)
+		if err != nil {
+			return err
+		}
+		if s.gsWrite == nil {
+			return fmt.Errorf("sheet write service is not configured")
+		}
+		if err := s.gsWrite.DeleteRow(s.cfg().SpreadsheetID, sheet, "id", signal.Tag); err != nil {
+			return fmt.Errorf("failed to delete signal from sheet: %w", err)
+		}
+	}
+	if err := s.signalRepo.Delete(signal); err != nil {
+		return err
+	}
+	return s.fbRepo.DeletePrimary(signal.Tag)
+}
+
+// dbOnlyWarning добавляет предупреждение, что изменение без push не записано в лист:
+// синхронизация очищает сигналы в БД и загружает их из листов заново
+func dbOnlyWarning(warnings []string, push bool) []string {
+	if push {
+		return warnings
+	}
+	return append(warnings, "signal is saved only in the database and will be replaced from the sheet on the next sync, use push=true to keep it")
+}
+
+func (s *SyncService) getSignal(id string) (*models.Signal, error) {
+	var signal models.Signal
+	if err := s.signalRepo.GetWithDetails(id, &signal); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: signal %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get signal: %w", err)
+	}
+	return &signal, nil
+}
+
+// resolveSignal проверяет запрос по правилам загрузки из листа: тип с настроенным
+// листом, существующие система и изделие, узел по нечеткому совпадению в системе
+func (s *SyncService) resolveSignal(in *models.SignalInput) (*models.Signal, []string, error) {
+	if err := in.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if _, err := s.signalSheet(in.SignalType); err != nil {
+		return nil, nil, err
+	}
+	system, err := s.systemRepo.GetSystemByName(strings.TrimSpace(in.System))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown system %q", ErrInvalid, in.System)
+	}
+	product, err := s.productRepo.GetByName(strings.TrimSpace(in.Product))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unknown product %q", ErrInvalid, in.Product)
+	}
+
+	signal := in.ToSignal()
+	signal.SystemID = &system.ID
+	signal.System = system
+	signal.ProductID = &product.ID
+	signal.Product = product
+	if strings.TrimSpace(in.Node) != "" {
+		overrides, err := s.nodeOverrides()
+		if err != nil {
+			return nil, nil, err
+		}
+		node, match, err := s.resolveNode(overrides, in.Tag, system.Name, system.ID, strings.TrimSpace(in.Node))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find node for %s: %w", in.Node, err)
+		}
+		if node == nil {
+			return nil, nil, fmt.Errorf("%w: node %q does not match any node of system %s (best score %.2f)", ErrInvalid, in.Node, system.Name, match.Score)
+		}
+		signal.NodeID = &node.ID
+		signal.Node = node
+		signal.NodeRef = node.Name
+		in.Node = node.Name
+	}
+
+	var warnings []string
+	catalogue, err := s.moduleCatalogue()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, issue := range catalogue.Apply(&signal) {
+		warnings = append(warnings, fmt.Sprintf("module %s, channel %s: %s", issue.Module, issue.Channel, issue.Reason))
+	}
+	return &signal, warnings, nil
+}
+
+// pushSignal записывает сигнал в строку листа его типа, найденную по тэгу key
+func (s *SyncService) pushSignal(in *models.SignalInput, key string) error {
+	sheet, err := s.signalSheet(in.SignalType)
+	if err != nil {
+		return err
+	}
+	if s.gsWrite == nil {
+		return fmt.Errorf("sheet write service is not configured")
+	}
+	if err := s.gsWrite.UpsertRow(s.cfg().SpreadsheetID, sheet, "id", key, in.SheetRow()); err != nil {
+		return fmt.Errorf("failed to write signal to sheet: %w", err)
+	}
+	return nil
+}
+
+// signalSheet возвращает имя листа сигналов заданного типа
+func (s *SyncService) signalSheet(signalType string) (string, error) {
+	for _, sheet := range s.cfg().Sheets {
+		if sheet.SignalType == signalType || sheet.SheetName == signalType {
+			return sheet.SheetName, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no sheet configured for signal type %s", ErrInvalid, signalType)
+}
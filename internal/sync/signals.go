@@ -3,8 +3,10 @@ package sync
 import (
 	"context"
 	"fmt"
+	"log"
 	"reflect"
 
+	"github.com/gin-gonic/gin"
 	"github.com/mejzh77/astragen/configs/config"
 	"github.com/mejzh77/astragen/internal/gsheets"
 	"github.com/mejzh77/astragen/pkg/models"
@@ -16,16 +18,37 @@ func (s *SyncService) LoadAndSaveSignals(ctx context.Context) ([]models.Signal,
 		return nil, fmt.Errorf("failed to load signals: %w", err)
 	}
 
+	issues, err := s.applyModuleCatalogue(signals)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		log.Printf("Signal %s (module %s, channel %s): %s", issue.SignalTag, issue.Module, issue.Channel, issue.Reason)
+	}
+	for _, issue := range s.checkModbusMap(signals) {
+		log.Printf("Modbus %s %s %d (%v): %s", issue.Node, issue.Type, issue.Address, issue.Tags, issue.Reason)
+	}
+
 	if err := s.signalRepo.SaveSignals(signals, false); err != nil {
 		return nil, fmt.Errorf("failed to save signals: %w", err)
 	}
 	return signals, nil
 }
 
+// LinkSignalsWithFuzzyMatching связывает сигналы с узлами. Ручные назначения применяются
+// до нечеткого сопоставления; результаты сохраняются для очереди проверки.
 func (s *SyncService) LinkSignalsWithFuzzyMatching(signals []models.Signal) error {
+	overrides, err := s.nodeOverrides()
+	if err != nil {
+		return err
+	}
+
+	var matches []models.NodeMatch
 	for i, signal := range signals {
 		if signal.NodeRef == "" {
-			continue
+			if _, ok := overrides.Find(signal.Tag, signal.SystemRef, ""); !ok {
+				continue
+			}
 		}
 
 		system, err := s.systemRepo.GetSystemByName(signal.SystemRef)
@@ -35,28 +58,40 @@ func (s *SyncService) LinkSignalsWithFuzzyMatching(signals []models.Signal) erro
 			continue
 		}
 
-		node, err := s.findBestNodeMatch(signal.NodeRef, system.ID)
+		node, match, err := s.resolveNode(overrides, signal.Tag, system.Name, system.ID, signal.NodeRef)
 		if err != nil {
 			return fmt.Errorf("failed to find node for %s: %w", signal.NodeRef, err)
 		}
+		if node == nil {
+			if match.Method == models.NodeMatchRejected {
+				log.Printf("Signal %s: node %q left unlinked, best match %q scored %.2f", signal.Tag, signal.NodeRef, match.Candidates[0].Name, match.Score)
+				match.SignalID = signal.ID
+				matches = append(matches, match)
+			}
+			continue
+		}
+		match.SignalID = signal.ID
+		matches = append(matches, match)
 
 		signals[i].NodeID = &node.ID
 		signals[i].NodeRef = node.Name
 	}
-
-	return s.signalRepo.UpdateSignalNodes(signals)
+	if err := s.signalRepo.UpdateSignalNodes(signals); err != nil {
+		return err
+	}
+	return s.matchRepo.ReplaceAll(matches)
 }
 
 func (s *SyncService) loadSignalsFromSheets(ctx context.Context) ([]models.Signal, error) {
 	var allSignals []models.Signal
 
-	for _, sheetCfg := range config.Cfg.Sheets {
+	for _, sheetCfg := range s.cfg().Sheets {
 		readRange, err := gsheets.GetRange(sheetCfg.SheetName, sheetCfg.Model, true)
 		if err != nil {
 			return nil, fmt.Errorf("failed to GetRange for sheet %s: %w", sheetCfg.SheetName, err)
 		}
 
-		rows, err := s.gsRead.ReadSheet(config.Cfg.SpreadsheetID, readRange)
+		rows, err := s.gsRead.ReadSheet(s.cfg().SpreadsheetID, readRange)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read sheet %s: %w", sheetCfg.SheetName, err)
 		}
@@ -130,3 +165,11 @@ func (s *SyncService) parseSheetData(rows [][]interface{}, sheetCfg config.Sheet
 
 	return signals, nil
 }
+
+func (s *SyncService) GetSignalDetails(id string) (gin.H, error) {
+	var signal models.Signal
+	if err := s.signalRepo.GetWithDetails(id, &signal); err != nil {
+		return nil, fmt.Errorf("failed to get signal details: %w", err)
+	}
+	return signal.ToDetailedAPI(), nil
+}
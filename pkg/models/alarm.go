@@ -0,0 +1,110 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Виды аварийных сообщений аналоговых сигналов (по уставкам)
+const (
+	AlarmLowLow   = "LL" // AL
+	AlarmLow      = "L"  // WL
+	AlarmHigh     = "H"  // WH
+	AlarmHighHigh = "HH" // AH
+	AlarmDiscrete = "DI" // Дискретный сигнал с категорией
+)
+
+// AlarmRow - строка таблицы аварийных сообщений SCADA
+type AlarmRow struct {
+	Tag      string
+	Kind     string // LL, L, H, HH или DI
+	Message  string
+	Category string
+	Priority int
+	Limit    string // Уставка, пусто для DI
+	Unit     string
+	Node     string
+	System   string
+	Signal   *Signal
+}
+
+// AlarmSettings - правила формирования аварийных сообщений
+type AlarmSettings struct {
+	// Priorities - приоритет по категории DI или виду уставки (LL, L, H, HH)
+	Priorities      map[string]int
+	DefaultPriority int
+	// Messages - текст сообщения по виду уставки или категории, добавляется к имени сигнала
+	Messages map[string]string
+}
+
+// BuildAlarms формирует аварийные сообщения: по одному на каждую заданную
+// уставку AI и по одному на DI с категорией
+func BuildAlarms(signals []Signal, settings AlarmSettings) []AlarmRow {
+	var rows []AlarmRow
+	for i := range signals {
+		s := &signals[i]
+		switch s.SignalType {
+		case "AI":
+			limits := []struct {
+				kind  string
+				value *float64
+			}{
+				{AlarmLowLow, s.AlarmLow},
+				{AlarmLow, s.WarningLow},
+				{AlarmHigh, s.WarningHigh},
+				{AlarmHighHigh, s.AlarmHigh},
+			}
+			for _, l := range limits {
+				if l.value == nil {
+					continue
+				}
+				rows = append(rows, newAlarmRow(s, l.kind, l.kind, l.value, settings))
+			}
+		case "DI":
+			if s.Category == nil || strings.TrimSpace(*s.Category) == "" {
+				continue
+			}
+			rows = append(rows, newAlarmRow(s, AlarmDiscrete, strings.TrimSpace(*s.Category), nil, settings))
+		}
+	}
+	return rows
+}
+
+func newAlarmRow(s *Signal, kind, category string, limit *float64, settings AlarmSettings) AlarmRow {
+	message := s.Name
+	if message == "" {
+		message = s.Comment
+	}
+	text, ok := settings.Messages[category]
+	if !ok {
+		text = settings.Messages[kind]
+	}
+	if text != "" {
+		message = strings.TrimSpace(message + " " + text)
+	}
+
+	priority, ok := settings.Priorities[category]
+	if !ok {
+		priority = settings.DefaultPriority
+	}
+
+	var unit, limitText string
+	if s.Unit != nil {
+		unit = *s.Unit
+	}
+	if limit != nil {
+		limitText = strconv.FormatFloat(*limit, 'f', -1, 64)
+	}
+	return AlarmRow{
+		Tag:      s.Tag,
+		Kind:     kind,
+		Message:  message,
+		Category: category,
+		Priority: priority,
+		Limit:    limitText,
+		Unit:     unit,
+		Node:     nodeName(s),
+		System:   systemName(s),
+		Signal:   s,
+	}
+}
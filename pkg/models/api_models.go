@@ -1,6 +1,10 @@
 package models
 
-import "github.com/gin-gonic/gin"
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
 
 func (p *Project) ToDetailedAPI() gin.H {
 	return gin.H{
@@ -85,14 +89,62 @@ func (fb *FunctionBlock) ToDetailedAPI() gin.H {
 		"id":        fb.ID,
 		"tag":       fb.Tag,
 		"call":      fb.Call,
+		"init":      fb.Init,
 		"omx":       fb.OMX,
 		"opc":       fb.OPC,
 		"type":      "functionblock",
 		"cdsType":   fb.CdsType,
+		"software":  fb.Software,
+		"wiring":    fb.Wiring,
 		"variables": fb.VariablesToDetailedAPI(),
 	}
 }
 
+// Для Signal
+func (s *Signal) ToDetailedAPI() gin.H {
+	details := gin.H{
+		"id":         s.ID,
+		"tag":        s.Tag,
+		"name":       s.Name,
+		"type":       "signal",
+		"signalType": s.SignalType,
+		"equipment":  s.Equipment,
+		"system":     systemName(s),
+		"node":       nodeName(s),
+		"product":    productTag(s),
+		"crate":      s.Crate,
+		"module":     s.Module,
+		"channel":    s.Channel,
+		"address":    s.Address,
+		"modbusAddr": s.ModbusAddr,
+		"fb":         s.FB,
+		"comment":    s.Comment,
+	}
+	if s.RangeMin != nil && s.RangeMax != nil {
+		details["range"] = fmt.Sprintf("%g..%g", *s.RangeMin, *s.RangeMax)
+	}
+	if s.Unit != nil {
+		details["unit"] = *s.Unit
+	}
+	return details
+}
+
+// ToSummaryAPI - ФБ в списке, без сгенерированного кода
+func (fb *FunctionBlock) ToSummaryAPI() gin.H {
+	return gin.H{
+		"id":          fb.ID,
+		"tag":         fb.Tag,
+		"name":        fb.Name,
+		"description": fb.Description,
+		"cdsType":     fb.CdsType,
+		"system":      systemName(fb),
+		"node":        nodeName(fb),
+		"primary":     fb.Primary,
+		"software":    fb.Software,
+		"variables":   len(fb.Variables),
+	}
+}
+
 func (fb *FunctionBlock) VariablesToDetailedAPI() []gin.H {
 	var vars []gin.H
 	for _, v := range fb.Variables {
@@ -102,6 +154,8 @@ func (fb *FunctionBlock) VariablesToDetailedAPI() []gin.H {
 			"signalTag": v.SignalTag,
 			"funcAttr":  v.FuncAttr,
 			"fbId":      v.FBID,
+			"source":    v.SourceTag(),
+			"sourcePin": v.SourcePin,
 			"createdAt": v.CreatedAt,
 			"updatedAt": v.UpdatedAt,
 		})
@@ -213,6 +267,8 @@ func (fb *FunctionBlock) VariablesToAPI() []gin.H {
 			"signalTag": v.SignalTag,
 			"funcAttr":  v.FuncAttr,
 			"fbId":      v.FBID,
+			"source":    v.SourceTag(),
+			"sourcePin": v.SourcePin,
 		})
 	}
 	return vars
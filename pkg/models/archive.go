@@ -0,0 +1,133 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ArchiveRule - правило отбора тэгов в архив. Правила проверяются по порядку,
+// применяется первое подходящее. Правило без Items отбирает сигналы
+// (по типу сигнала, типу ФБ и категории), с Items - пути составных ФБ типа CdsType.
+type ArchiveRule struct {
+	SignalType string
+	CdsType    string
+	Category   string
+	Items      []string
+	Deadband   float64 // Зона нечувствительности, % диапазона RangeMax-RangeMin
+	SampleRate string
+	Exclude    bool
+}
+
+// DefaultArchiveRules - по умолчанию архивируются аналоговые входы
+var DefaultArchiveRules = []ArchiveRule{
+	{SignalType: "AI", Deadband: 1, SampleRate: "1s"},
+}
+
+// ArchiveRow - строка конфигурации архива
+type ArchiveRow struct {
+	Tag             string
+	Path            string // Путь в ПЛК: "AI.TT101", "MTR.M101.q_xOn"
+	Name            string
+	Unit            string
+	CdsType         string
+	SampleRate      string
+	Deadband        string // Абсолютная зона нечувствительности
+	DeadbandPercent float64
+	RangeMin        string
+	RangeMax        string
+	Node            string
+	System          string
+}
+
+func (r ArchiveRule) matchesSignal(s *Signal) bool {
+	if len(r.Items) > 0 {
+		return false
+	}
+	if r.SignalType != "" && r.SignalType != s.SignalType {
+		return false
+	}
+	if r.CdsType != "" && r.CdsType != s.FB {
+		return false
+	}
+	if r.Category != "" && (s.Category == nil || strings.TrimSpace(*s.Category) != r.Category) {
+		return false
+	}
+	return true
+}
+
+func (r ArchiveRule) matchesFB(fb *FunctionBlock) bool {
+	return len(r.Items) > 0 && !fb.Primary && r.CdsType == fb.CdsType
+}
+
+// BuildArchive формирует список архивируемых тэгов по правилам
+func BuildArchive(signals []Signal, fbs []*FunctionBlock, rules []ArchiveRule) []ArchiveRow {
+	if len(rules) == 0 {
+		rules = DefaultArchiveRules
+	}
+
+	var rows []ArchiveRow
+	for i := range signals {
+		s := &signals[i]
+		for _, rule := range rules {
+			if !rule.matchesSignal(s) {
+				continue
+			}
+			if !rule.Exclude {
+				rows = append(rows, signalArchiveRow(s, rule))
+			}
+			break
+		}
+	}
+
+	for _, fb := range fbs {
+		for _, rule := range rules {
+			if !rule.matchesFB(fb) {
+				continue
+			}
+			if rule.Exclude {
+				break
+			}
+			for _, item := range rule.Items {
+				rows = append(rows, ArchiveRow{
+					Tag:        fb.Tag,
+					Path:       fb.CdsType + "." + fb.Tag + "." + item,
+					Name:       fb.Name,
+					CdsType:    fb.CdsType,
+					SampleRate: rule.SampleRate,
+					Node:       nodeName(fb),
+					System:     systemName(fb),
+				})
+			}
+			break
+		}
+	}
+	return rows
+}
+
+func signalArchiveRow(s *Signal, rule ArchiveRule) ArchiveRow {
+	row := ArchiveRow{
+		Tag:             s.Tag,
+		Path:            s.SignalType + "." + s.Tag,
+		Name:            s.Name,
+		CdsType:         s.SignalType,
+		SampleRate:      rule.SampleRate,
+		DeadbandPercent: rule.Deadband,
+		Node:            nodeName(s),
+		System:          systemName(s),
+	}
+	if s.Unit != nil {
+		row.Unit = *s.Unit
+	}
+	if s.RangeMin != nil && s.RangeMax != nil {
+		row.RangeMin = strconv.FormatFloat(*s.RangeMin, 'f', -1, 64)
+		row.RangeMax = strconv.FormatFloat(*s.RangeMax, 'f', -1, 64)
+		if rule.Deadband > 0 {
+			deadband := (*s.RangeMax - *s.RangeMin) * rule.Deadband / 100
+			if deadband < 0 {
+				deadband = -deadband
+			}
+			row.Deadband = strconv.FormatFloat(deadband, 'f', -1, 64)
+		}
+	}
+	return row
+}
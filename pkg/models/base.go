@@ -23,25 +23,26 @@ type Base struct {
 type DI struct {
 	Base `gsheets:",squash"`
 
-	Category  string  `gsheets:"cat"`
-	Inversion string  `gsheets:"inversion"`
-	TON       float64 `gsheets:"ton"`
-	TOF       float64 `gsheets:"tof"`
+	Category  string   `gsheets:"cat"`
+	Inversion string   `gsheets:"inversion"`
+	TON       *float64 `gsheets:"ton"` // nil - пустая ячейка
+	TOF       *float64 `gsheets:"tof"`
 }
 
 type AI struct {
 	Base `gsheets:",squash"`
 
-	YMIN   float64 `gsheets:"YMIN"`
-	YMAX   float64 `gsheets:"YMAX"`
-	Unit   string  `gsheets:"unit"`
-	Sign   string  `gsheets:"sign"`
-	WL     float64 `gsheets:"WL"`
-	WH     float64 `gsheets:"WH"`
-	AL     float64 `gsheets:"AL"`
-	AH     float64 `gsheets:"AH"`
-	Format string  `gsheets:"format"`
-	Filter string  `gsheets:"filter"`
+	// Диапазон и уставки: nil - пустая ячейка (уставка не задана)
+	YMIN   *float64 `gsheets:"YMIN"`
+	YMAX   *float64 `gsheets:"YMAX"`
+	Unit   string   `gsheets:"unit"`
+	Sign   string   `gsheets:"sign"`
+	WL     *float64 `gsheets:"WL"`
+	WH     *float64 `gsheets:"WH"`
+	AL     *float64 `gsheets:"AL"`
+	AH     *float64 `gsheets:"AH"`
+	Format string   `gsheets:"format"`
+	Filter string   `gsheets:"filter"`
 }
 
 type DQ struct {
@@ -76,4 +77,8 @@ type SheetFB struct {
 	Tag         string `gsheets:"tag"`
 	Name        string `gsheets:"name"`
 	Description string `gsheets:"description"`
+	Node        string `gsheets:"node"`
+	Pins        string `gsheets:"pins"`    // Подключение входов/выходов: "pin=FB.output; ..."
+	Signals     string `gsheets:"signals"` // Ручные привязки сигналов: "attr=TAG; attr=-" ("-" - отвязать)
+	Origin      string `gsheets:"origin"`  // sheet - объявлен в листе (программный), signal или пусто - из сигналов
 }
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ConfigVersion - сохраненная версия файла конфига. Content хранится со скрытыми
+// секретами, Diff - изменения относительно предыдущей версии
+type ConfigVersion struct {
+	ID        uint      `gorm:"primarykey" json:"version"`
+	Author    string    `gorm:"size:255" json:"author"`
+	Comment   string    `json:"comment"`
+	Content   string    `gorm:"type:text;not null" json:"content,omitempty"`
+	Diff      string    `gorm:"type:text" json:"diff,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// diffContext - число неизмененных строк вокруг изменений в UnifiedDiff
+const diffContext = 3
+
+// UnifiedDiff возвращает построчные различия текстов в формате unified diff;
+// пустая строка - тексты совпадают
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	a, b := splitLines(oldText), splitLines(newText)
+	ops := diffLines(a, b)
+
+	var buf strings.Builder
+	for start := 0; start < len(ops); {
+		// Ищем следующее изменение
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Блок: изменения, между которыми не больше 2*diffContext общих строк
+		from := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		to := min(end+diffContext, len(ops))
+
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		oldStart, newStart := ops[from].oldLine, ops[from].newLine
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", oldStart+1, oldCount, newStart+1, newCount)
+		for _, op := range ops[from:to] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.text)
+			buf.WriteByte('\n')
+		}
+		start = to
+	}
+	return buf.String()
+}
+
+// diffOp - строка результата сравнения: ' ' - общая, '-' - удалена, '+' - добавлена.
+// oldLine, newLine - номера строки (с 0) в старом и новом тексте в этом месте
+type diffOp struct {
+	kind             byte
+	text             string
+	oldLine, newLine int
+}
+
+// diffLines сравнивает строки по наибольшей общей подпоследовательности
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
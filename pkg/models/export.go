@@ -0,0 +1,51 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// RenderTable формирует файл выгрузки по шаблонам: header и footer выполняются
+// один раз с полным списком записей, row - для каждой записи rows (срез).
+// В шаблонах доступны TemplateFuncs (xml_escape, pad_left, default, ...).
+func RenderTable(header, row, footer string, rows interface{}) (string, error) {
+	rowTmpl, err := newTemplate("row").Parse(row)
+	if err != nil {
+		return "", fmt.Errorf("invalid row template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderPart(&buf, "header", header, rows); err != nil {
+		return "", err
+	}
+	items := reflect.ValueOf(rows)
+	if items.Kind() != reflect.Slice {
+		return "", fmt.Errorf("rows must be a slice, got %T", rows)
+	}
+	for i := 0; i < items.Len(); i++ {
+		if err := rowTmpl.Execute(&buf, items.Index(i).Interface()); err != nil {
+			return "", fmt.Errorf("failed to render row %d: %w", i, err)
+		}
+		buf.WriteString("\n")
+	}
+	if err := renderPart(&buf, "footer", footer, rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderPart(buf *bytes.Buffer, name, text string, data interface{}) error {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := newTemplate(name).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	buf.WriteString("\n")
+	return nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FBUpdate - поля ФБ, изменяемые через API; nil - поле не меняется
+type FBUpdate struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Node        *string `json:"node"`
+	CdsType     *string `json:"cdsType"`
+}
+
+// FBVariableInput - ручная привязка входа/выхода ФБ к сигналу или выходу другого ФБ
+type FBVariableInput struct {
+	FuncAttr  string `json:"funcAttr"`  // Атрибут сигнала или вход/выход ФБ для связи ФБ-ФБ
+	Direction string `json:"direction"` // input, output или пусто - по конфигурации типа ФБ
+	SignalTag string `json:"signalTag"`
+	Source    string `json:"source"` // Тэг ФБ-источника для связи ФБ-ФБ
+	SourcePin string `json:"sourcePin"`
+}
+
+// Validate проверяет, что задан атрибут и ровно один источник: сигнал или ФБ
+func (in *FBVariableInput) Validate() error {
+	in.FuncAttr = strings.TrimSpace(in.FuncAttr)
+	in.SignalTag = strings.TrimSpace(in.SignalTag)
+	in.Source = strings.TrimSpace(in.Source)
+	in.SourcePin = strings.TrimSpace(in.SourcePin)
+	in.Direction = strings.ToLower(strings.TrimSpace(in.Direction))
+
+	if in.FuncAttr == "" {
+		return fmt.Errorf("funcAttr is required")
+	}
+	if (in.SignalTag == "") == (in.Source == "") {
+		return fmt.Errorf("either signalTag or source must be set")
+	}
+	if in.Direction != "" && in.Direction != "input" && in.Direction != "output" {
+		return fmt.Errorf("invalid direction %q", in.Direction)
+	}
+	return nil
+}
+
+// IsLink сообщает, что привязка связывает ФБ с другим ФБ
+func (in *FBVariableInput) IsLink() bool {
+	return in.Source != ""
+}
+
+// ResolveDirection определяет направление привязки по входам/выходам типа ФБ.
+// Для сигнала атрибут сравнивается с правой частью in/out ("open" в "i_xOpen: open.VALUE"),
+// для связи ФБ-ФБ - с именем входа/выхода. Пустые in/out (тип без конфигурации) не проверяются.
+func (in *FBVariableInput) ResolveDirection(pairIn, pairOut map[string]string) (string, error) {
+	matches := func(pairs map[string]string) bool {
+		for lhs, rhs := range pairs {
+			if in.IsLink() && lhs == in.FuncAttr {
+				return true
+			}
+			if !in.IsLink() && strings.Split(rhs, ".")[0] == in.FuncAttr {
+				return true
+			}
+		}
+		return false
+	}
+	isIn, isOut := matches(pairIn), matches(pairOut)
+
+	switch {
+	case in.Direction == "input" && (isIn || len(pairIn) == 0):
+		return "input", nil
+	case in.Direction == "output" && (isOut || len(pairOut) == 0):
+		return "output", nil
+	case in.Direction == "" && isOut && !isIn:
+		return "output", nil
+	case in.Direction == "" && (isIn || len(pairIn)+len(pairOut) == 0):
+		return "input", nil
+	}
+	kind := "pin"
+	if in.Direction != "" {
+		kind = in.Direction + " pin"
+	}
+	return "", fmt.Errorf("%s is not a known %s of the function block type", in.FuncAttr, kind)
+}
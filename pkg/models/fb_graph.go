@@ -0,0 +1,191 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FBGraph - граф зависимостей ФБ, построенный по связям ФБ-ФБ
+type FBGraph struct {
+	Nodes []FBGraphNode `json:"nodes"`
+	Edges []FBGraphEdge `json:"edges"`
+}
+
+// FBGraphNode - вершина графа (ФБ)
+type FBGraphNode struct {
+	ID      uint   `json:"id"`
+	Tag     string `json:"tag"`
+	CdsType string `json:"cdsType"`
+	Node    string `json:"node,omitempty"`
+}
+
+// FBGraphEdge - ребро графа: From вычисляется раньше To
+type FBGraphEdge struct {
+	From    uint   `json:"from"`
+	To      uint   `json:"to"`
+	FromPin string `json:"fromPin,omitempty"`
+	ToPin   string `json:"toPin"`
+}
+
+// BuildFBGraph строит граф по переменным-связям ФБ. Связи с ФБ, не вошедшими
+// в fbs, пропускаются. Для входа ребро идет от ФБ-источника к ФБ,
+// для выхода - от ФБ к ФБ-получателю.
+func BuildFBGraph(fbs []*FunctionBlock) *FBGraph {
+	g := &FBGraph{Nodes: make([]FBGraphNode, 0, len(fbs)), Edges: []FBGraphEdge{}}
+	known := make(map[uint]bool, len(fbs))
+	for _, fb := range fbs {
+		known[fb.ID] = true
+		g.Nodes = append(g.Nodes, FBGraphNode{
+			ID:      fb.ID,
+			Tag:     fb.Tag,
+			CdsType: fb.CdsType,
+			Node:    nodeName(fb),
+		})
+	}
+
+	for _, fb := range fbs {
+		for i := range fb.Variables {
+			v := &fb.Variables[i]
+			if !v.IsLink() || !known[*v.SourceFBID] {
+				continue
+			}
+			edge := FBGraphEdge{From: *v.SourceFBID, To: fb.ID, FromPin: v.SourcePin, ToPin: v.FuncAttr}
+			if v.Direction == "output" {
+				edge = FBGraphEdge{From: fb.ID, To: *v.SourceFBID, FromPin: v.FuncAttr, ToPin: v.SourcePin}
+			}
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	return g
+}
+
+// Cycles возвращает циклы графа (сильно связные компоненты) в виде списков тэгов
+func (g *FBGraph) Cycles() [][]string {
+	index := make(map[uint]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.ID] = i
+	}
+	adj := make([][]int, len(g.Nodes))
+	selfLoop := make([]bool, len(g.Nodes))
+	for _, e := range g.Edges {
+		from, to := index[e.From], index[e.To]
+		adj[from] = append(adj[from], to)
+		if from == to {
+			selfLoop[from] = true
+		}
+	}
+
+	// Алгоритм Тарьяна
+	var (
+		counter int
+		stack   []int
+		cycles  [][]string
+		order   = make([]int, len(g.Nodes))
+		low     = make([]int, len(g.Nodes))
+		onStack = make([]bool, len(g.Nodes))
+	)
+	var visit func(v int)
+	visit = func(v int) {
+		counter++
+		order[v], low[v] = counter, counter
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range adj[v] {
+			if order[w] == 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], order[w])
+			}
+		}
+		if low[v] != order[v] {
+			return
+		}
+		var component []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, g.Nodes[w].Tag)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop[v] {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for v := range g.Nodes {
+		if order[v] == 0 {
+			visit(v)
+		}
+	}
+	return cycles
+}
+
+// TopoOrder возвращает ID ФБ в порядке вычисления: источники раньше получателей.
+// Независимые ФБ сохраняют исходный порядок. При наличии цикла возвращает ошибку.
+func (g *FBGraph) TopoOrder() ([]uint, error) {
+	index := make(map[uint]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.ID] = i
+	}
+	adj := make([][]int, len(g.Nodes))
+	inDegree := make([]int, len(g.Nodes))
+	for _, e := range g.Edges {
+		from, to := index[e.From], index[e.To]
+		adj[from] = append(adj[from], to)
+		inDegree[to]++
+	}
+
+	// Алгоритм Кана; ready упорядочен по исходному индексу
+	var ready []int
+	for i, d := range inDegree {
+		if d == 0 {
+			ready = append(ready, i)
+		}
+	}
+	order := make([]uint, 0, len(g.Nodes))
+	for len(ready) > 0 {
+		v := ready[0]
+		ready = ready[1:]
+		order = append(order, g.Nodes[v].ID)
+		for _, w := range adj[v] {
+			inDegree[w]--
+			if inDegree[w] == 0 {
+				pos := sort.SearchInts(ready, w)
+				ready = append(ready[:pos], append([]int{w}, ready[pos:]...)...)
+			}
+		}
+	}
+
+	if len(order) < len(g.Nodes) {
+		// Компонента - множество ФБ, а не путь: порядок тэгов не отражает ребра
+		var cycles []string
+		for _, c := range g.Cycles() {
+			cycles = append(cycles, "{"+strings.Join(c, ", ")+"}")
+		}
+		return nil, fmt.Errorf("FB dependency cycle: %s", strings.Join(cycles, "; "))
+	}
+	return order, nil
+}
+
+// SortByDependencies упорядочивает ФБ так, чтобы источники вызывались раньше
+// получателей. При цикле возвращает исходный порядок и ошибку.
+func SortByDependencies(fbs []*FunctionBlock) ([]*FunctionBlock, error) {
+	order, err := BuildFBGraph(fbs).TopoOrder()
+	if err != nil {
+		return fbs, err
+	}
+	byID := make(map[uint]*FunctionBlock, len(fbs))
+	for _, fb := range fbs {
+		byID[fb.ID] = fb
+	}
+	sorted := make([]*FunctionBlock, 0, len(fbs))
+	for _, id := range order {
+		sorted = append(sorted, byID[id])
+	}
+	return sorted, nil
+}
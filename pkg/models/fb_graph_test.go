@@ -0,0 +1,125 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// graphFB создает ФБ с переменными-связями links
+func graphFB(id uint, tag string, links ...FBVariable) *FunctionBlock {
+	fb := &FunctionBlock{Tag: tag, Variables: links}
+	fb.ID = id
+	return fb
+}
+
+func linkFrom(src uint) FBVariable {
+	return FBVariable{Direction: "input", FuncAttr: "i_x", SourceFBID: &src, SourcePin: "q_x"}
+}
+
+func linkTo(dst uint) FBVariable {
+	return FBVariable{Direction: "output", FuncAttr: "q_x", SourceFBID: &dst, SourcePin: "i_x"}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		fbs     []*FunctionBlock
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "chain",
+			fbs:  []*FunctionBlock{graphFB(3, "C", linkFrom(2)), graphFB(2, "B", linkFrom(1)), graphFB(1, "A")},
+			want: []string{"A", "B", "C"},
+		},
+		{
+			name: "independent keep order",
+			fbs:  []*FunctionBlock{graphFB(2, "B"), graphFB(1, "A"), graphFB(3, "C")},
+			want: []string{"B", "A", "C"},
+		},
+		{
+			name: "diamond",
+			fbs: []*FunctionBlock{
+				graphFB(4, "D", linkFrom(2), linkFrom(3)),
+				graphFB(3, "C", linkFrom(1)),
+				graphFB(2, "B", linkFrom(1)),
+				graphFB(1, "A"),
+			},
+			want: []string{"A", "C", "B", "D"},
+		},
+		{
+			name: "output link",
+			fbs:  []*FunctionBlock{graphFB(2, "B"), graphFB(1, "A", linkTo(2))},
+			want: []string{"A", "B"},
+		},
+		{
+			name: "link to unknown FB",
+			fbs:  []*FunctionBlock{graphFB(2, "B", linkFrom(9)), graphFB(1, "A")},
+			want: []string{"B", "A"},
+		},
+		{
+			name:    "cycle",
+			fbs:     []*FunctionBlock{graphFB(3, "C"), graphFB(2, "B", linkFrom(1)), graphFB(1, "A", linkFrom(2))},
+			want:    []string{"C", "B", "A"},
+			wantErr: "FB dependency cycle: {A, B}",
+		},
+		{
+			name: "cycle of three",
+			fbs: []*FunctionBlock{
+				graphFB(1, "Z", linkFrom(3)),
+				graphFB(2, "A", linkFrom(1)),
+				graphFB(3, "M", linkFrom(2)),
+			},
+			want:    []string{"Z", "A", "M"},
+			wantErr: "FB dependency cycle: {A, M, Z}",
+		},
+		{
+			name:    "self loop",
+			fbs:     []*FunctionBlock{graphFB(1, "A", linkFrom(1))},
+			want:    []string{"A"},
+			wantErr: "FB dependency cycle: {A}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := SortByDependencies(tt.fbs)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("SortByDependencies error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("SortByDependencies: %v", err)
+			}
+			got := make([]string, 0, len(sorted))
+			for _, fb := range sorted {
+				got = append(got, fb.Tag)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortByDependencies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopoOrderSeveralCycles(t *testing.T) {
+	g := BuildFBGraph([]*FunctionBlock{
+		graphFB(1, "A", linkFrom(2)),
+		graphFB(2, "B", linkFrom(1)),
+		graphFB(3, "C", linkFrom(4)),
+		graphFB(4, "D", linkFrom(3)),
+		graphFB(5, "E", linkFrom(1)),
+	})
+	order, err := g.TopoOrder()
+	if err == nil {
+		t.Fatalf("TopoOrder = %v, want cycle error", order)
+	}
+	for _, want := range []string{"{A, B}", "{C, D}"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("TopoOrder error = %q, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "E") {
+		t.Errorf("TopoOrder error = %q reports E, which is not in a cycle", err)
+	}
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateSTInit формирует секцию инициализации параметров экземпляра ФБ.
+//
+// params сопоставляет имя параметра ФБ с источником значения:
+//
+//	RangeMin          — поле исходного сигнала первичного ФБ
+//	start.TON         — поле сигнала переменной с FuncAttr "start"
+//	{{ ... }}         — шаблон с данными FBCallParams и TemplateFuncs
+//
+// Параметры с пустым значением (nil, "") пропускаются.
+func (fb *FunctionBlock) GenerateSTInit(params map[string]string, defaultInputs, defaultOutputs map[string]string) (string, error) {
+	if len(params) == 0 {
+		return "", nil
+	}
+	data := fb.CallParams(defaultInputs, defaultOutputs)
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		value, err := resolveParam(params[name], data)
+		if err != nil {
+			return "", fmt.Errorf("param %s: %w", name, err)
+		}
+		if value == "" {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("%s.%s.%s := %s;\n", fb.CdsType, fb.Tag, name, value))
+	}
+	return buf.String(), nil
+}
+
+// resolveParam вычисляет значение параметра в виде литерала ST
+func resolveParam(source string, data FBCallParams) (string, error) {
+	if strings.Contains(source, "{{") {
+		value, err := executeTemplate(source, data)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	signal, field := data.Signal, source
+	if attr, f, ok := strings.Cut(source, "."); ok {
+		signal, field = data.Signals[attr], f
+	}
+	if signal == nil {
+		return "", nil
+	}
+
+	v := reflect.ValueOf(*signal).FieldByName(field)
+	if !v.IsValid() {
+		return "", fmt.Errorf("signal has no field %q", field)
+	}
+	return stLiteral(v), nil
+}
+
+// stLiteral преобразует значение поля сигнала в литерал ST
+func stLiteral(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return stBool(v.Bool())
+	case reflect.String:
+		s := strings.TrimSpace(v.String())
+		if s == "" {
+			return ""
+		}
+		if b, ok := parseSheetBool(s); ok {
+			return stBool(b)
+		}
+		if _, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64); err == nil {
+			return strings.Replace(s, ",", ".", 1)
+		}
+		return "'" + stEscape(s) + "'"
+	}
+	return ""
+}
+
+func stBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// parseSheetBool распознает логические значения, принятые в таблицах
+func parseSheetBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "да", "+", "инв", "инверсия":
+		return true, true
+	case "false", "no", "нет", "-":
+		return false, true
+	}
+	return false, false
+}
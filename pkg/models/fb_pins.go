@@ -0,0 +1,61 @@
+package models
+
+import "sort"
+
+// FBCompleteness - отчет о неподключенных обязательных входах/выходах ФБ
+type FBCompleteness struct {
+	ID         uint     `json:"id"`
+	Tag        string   `json:"tag"`
+	CdsType    string   `json:"cdsType"`
+	Node       string   `json:"node"`
+	MissingIn  []string `json:"missingIn"`
+	MissingOut []string `json:"missingOut"`
+}
+
+// Complete возвращает true, если все обязательные входы/выходы подключены
+func (c FBCompleteness) Complete() bool {
+	return len(c.MissingIn) == 0 && len(c.MissingOut) == 0
+}
+
+// CheckCompleteness проверяет, что обязательные входы/выходы ФБ подключены
+// к сигналам. Входы/выходы со значением по умолчанию не считаются пропущенными
+func (fb *FunctionBlock) CheckCompleteness(pairIn, pairOut map[string]string, required []string, defaults map[string]string) FBCompleteness {
+	inSignals, outSignals := ProcessIOSignals(pairIn, pairOut, fb)
+
+	report := FBCompleteness{
+		ID:      fb.ID,
+		Tag:     fb.Tag,
+		CdsType: fb.CdsType,
+		Node:    nodeName(fb),
+	}
+	wiring, _ := ParseWiring(fb.Wiring)
+	for _, pin := range required {
+		if _, ok := defaults[pin]; ok {
+			continue
+		}
+		if _, ok := wiring[pin]; ok {
+			continue
+		}
+		if _, isIn := pairIn[pin]; isIn && inSignals[pin] == nil {
+			report.MissingIn = append(report.MissingIn, pin)
+		}
+		if _, isOut := pairOut[pin]; isOut && outSignals[pin] == nil {
+			report.MissingOut = append(report.MissingOut, pin)
+		}
+	}
+	sort.Strings(report.MissingIn)
+	sort.Strings(report.MissingOut)
+	return report
+}
+
+// applyDefaults подставляет значения по умолчанию в неподключенные входы/выходы
+func (p *FBCallParams) applyDefaults(pairIn, pairOut, defaults map[string]string) {
+	for pin, value := range defaults {
+		if _, ok := pairIn[pin]; ok && p.In[pin] == "" {
+			p.In[pin] = value
+		}
+		if _, ok := pairOut[pin]; ok && p.Out[pin] == "" {
+			p.Out[pin] = value
+		}
+	}
+}
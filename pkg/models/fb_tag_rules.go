@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FBTagRule описывает правило разбора тэга сигнала на тэг ФБ и атрибут.
+// Правило задается регулярным выражением с именованными группами fb и attr
+// либо шаблоном вида "{fb}_{attr}", "{fb}.{attr}_A1".
+type FBTagRule struct {
+	Source string
+	re     *regexp.Regexp
+}
+
+// FBTagIssue - сигнал составного ФБ, который не удалось привязать к ФБ
+type FBTagIssue struct {
+	SignalTag string `json:"signalTag"`
+	CdsType   string `json:"cdsType"`
+	FBTag     string `json:"fbTag,omitempty"`
+	FuncAttr  string `json:"funcAttr,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// CompileFBTagRule компилирует правило из регулярного выражения или шаблона
+func CompileFBTagRule(pattern, tmpl string) (*FBTagRule, error) {
+	source := pattern
+	if pattern == "" {
+		if tmpl == "" {
+			return nil, fmt.Errorf("tag rule must have pattern or template")
+		}
+		source = tmpl
+		pattern = tagTemplateToRegex(tmpl)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag rule %q: %w", source, err)
+	}
+	if re.SubexpIndex("fb") < 0 || re.SubexpIndex("attr") < 0 {
+		return nil, fmt.Errorf("tag rule %q must define fb and attr groups", source)
+	}
+	return &FBTagRule{Source: source, re: re}, nil
+}
+
+// tagTemplateToRegex преобразует шаблон "{fb}_{attr}" в регулярное выражение.
+// Тэг ФБ захватывается жадно, поэтому атрибут - последний сегмент.
+func tagTemplateToRegex(tmpl string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for rest := tmpl; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "{fb}"):
+			b.WriteString("(?P<fb>.+)")
+			rest = rest[len("{fb}"):]
+		case strings.HasPrefix(rest, "{attr}"):
+			b.WriteString("(?P<attr>[^._\\-]+)")
+			rest = rest[len("{attr}"):]
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Parse разбирает тэг сигнала по правилу
+func (r *FBTagRule) Parse(signalTag string) (fbTag, funcAttr string, ok bool) {
+	m := r.re.FindStringSubmatch(signalTag)
+	if m == nil {
+		return "", "", false
+	}
+	fbTag, funcAttr = m[r.re.SubexpIndex("fb")], m[r.re.SubexpIndex("attr")]
+	return fbTag, funcAttr, fbTag != "" && funcAttr != ""
+}
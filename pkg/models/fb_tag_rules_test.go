@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileFBTagRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		tmpl     string
+		wantErr  string
+		wantExpr string
+	}{
+		{"template", "", "{fb}_{attr}", "", `^(?P<fb>.+)_(?P<attr>[^._\-]+)$`},
+		{"template with literal suffix", "", "{fb}.{attr}_A1", "", `^(?P<fb>.+)\.(?P<attr>[^._\-]+)_A1$`},
+		{"pattern", `^(?P<fb>[A-Z]+\d+)(?P<attr>[a-z]+)$`, "", "", `^(?P<fb>[A-Z]+\d+)(?P<attr>[a-z]+)$`},
+		{"pattern wins over template", `^(?P<fb>.+)-(?P<attr>.+)$`, "{fb}_{attr}", "", `^(?P<fb>.+)-(?P<attr>.+)$`},
+		{"empty", "", "", "must have pattern or template", ""},
+		{"invalid regex", "(", "", "invalid tag rule", ""},
+		{"no attr group", `^(?P<fb>.+)$`, "", "must define fb and attr groups", ""},
+		{"template without fb", "", "X_{attr}", "must define fb and attr groups", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := CompileFBTagRule(tt.pattern, tt.tmpl)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CompileFBTagRule(%q, %q) error = %v, want %q", tt.pattern, tt.tmpl, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileFBTagRule(%q, %q): %v", tt.pattern, tt.tmpl, err)
+			}
+			if got := rule.re.String(); got != tt.wantExpr {
+				t.Errorf("CompileFBTagRule(%q, %q) regex = %q, want %q", tt.pattern, tt.tmpl, got, tt.wantExpr)
+			}
+		})
+	}
+}
+
+func TestFBTagRuleParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		tmpl     string
+		tag      string
+		wantFB   string
+		wantAttr string
+		wantOK   bool
+	}{
+		{"template match", "", "{fb}_{attr}", "VLV101_open", "VLV101", "open", true},
+		{"template attr is last segment", "", "{fb}_{attr}", "P_101_run", "P_101", "run", true},
+		{"template no separator", "", "{fb}_{attr}", "VLV101", "", "", false},
+		{"template literal suffix", "", "{fb}.{attr}_A1", "VLV101.open_A1", "VLV101", "open", true},
+		{"template literal suffix miss", "", "{fb}.{attr}_A1", "VLV101.open_A2", "", "", false},
+		{"template empty attr", "", "{fb}_{attr}", "VLV101_", "", "", false},
+		{"pattern match", `^(?P<fb>[A-Z]+\d+)(?P<attr>[a-z]+)$`, "", "PT101hi", "PT101", "hi", true},
+		{"pattern miss", `^(?P<fb>[A-Z]+\d+)(?P<attr>[a-z]+)$`, "", "PT101_hi", "", "", false},
+		{"pattern optional attr empty", `^(?P<fb>[A-Z]+)(?P<attr>[a-z]*)$`, "", "PT", "PT", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := CompileFBTagRule(tt.pattern, tt.tmpl)
+			if err != nil {
+				t.Fatalf("CompileFBTagRule(%q, %q): %v", tt.pattern, tt.tmpl, err)
+			}
+			fb, attr, ok := rule.Parse(tt.tag)
+			if fb != tt.wantFB || attr != tt.wantAttr || ok != tt.wantOK {
+				t.Errorf("%s.Parse(%q) = %q, %q, %v, want %q, %q, %v", rule.Source, tt.tag, fb, attr, ok, tt.wantFB, tt.wantAttr, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Значения колонки origin листа FB
+const (
+	FBOriginSignal = "signal" // ФБ создан из сигналов; пустое значение означает то же
+	FBOriginSheet  = "sheet"  // ФБ объявлен в листе FB (программный), задается явно
+)
+
+// FBBindingNone в колонке signals листа FB отвязывает сигнал, привязанный при синхронизации
+const FBBindingNone = "-"
+
+// ParseWiring разбирает явное подключение входов/выходов ФБ из листа FB:
+// "i_xOpen=VLV101.q_xOpened; i_rSP=PID1.q_rOut". Разделители - ';' или перевод строки
+func ParseWiring(text string) (map[string]string, error) {
+	wiring := make(map[string]string)
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '\n' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pin, ref, ok := strings.Cut(item, "=")
+		pin, ref = strings.TrimSpace(pin), strings.TrimSpace(ref)
+		if !ok || pin == "" || ref == "" {
+			return nil, fmt.Errorf("invalid pin wiring %q, expected pin=FB.output", item)
+		}
+		wiring[pin] = ref
+	}
+	return wiring, nil
+}
+
+// FormatWiring собирает подключение обратно в строку для листа FB
+func FormatWiring(wiring map[string]string) string {
+	pins := make([]string, 0, len(wiring))
+	for pin := range wiring {
+		pins = append(pins, pin)
+	}
+	sort.Strings(pins)
+
+	items := make([]string, 0, len(pins))
+	for _, pin := range pins {
+		items = append(items, pin+"="+wiring[pin])
+	}
+	return strings.Join(items, "; ")
+}
+
+// SheetRow возвращает строку листа FB для ФБ; System и Node должны быть загружены
+func (fb *FunctionBlock) SheetRow() SheetFB {
+	sys, node, origin := "--", fb.NodeRef, FBOriginSignal
+	if fb.System != nil {
+		sys = fb.System.Name
+	}
+	if fb.Node != nil {
+		node = fb.Node.Name
+	}
+	if fb.Software {
+		origin = FBOriginSheet
+	}
+	return SheetFB{
+		Name:        fb.Name,
+		Tag:         fb.Tag,
+		Description: fb.Description,
+		CdsType:     fb.CdsType,
+		System:      sys,
+		Node:        node,
+		Pins:        fb.Wiring,
+		Signals:     fb.Bindings,
+		Origin:      origin,
+	}
+}
+
+// WiringTags возвращает тэги ФБ, на которые ссылается подключение
+func WiringTags(wiring map[string]string) []string {
+	var tags []string
+	for _, ref := range wiring {
+		tag, _ := SplitWiringRef(ref)
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// SplitWiringRef разбирает ссылку "VLV101.q_xOpened" на тэг ФБ и вход/выход
+func SplitWiringRef(ref string) (tag, pin string) {
+	tag, pin, _ = strings.Cut(ref, ".")
+	return tag, pin
+}
+
+// applyLinks подставляет явно подключенные входы/выходы ФБ
+func (p *FBCallParams) applyLinks(pairIn, pairOut, links map[string]string) {
+	for pin, expr := range links {
+		if _, ok := pairIn[pin]; ok {
+			p.In[pin] = expr
+		}
+		if _, ok := pairOut[pin]; ok {
+			p.Out[pin] = expr
+		}
+	}
+}
@@ -3,7 +3,6 @@ package models
 import (
 	"bytes"
 	"fmt"
-	"strconv"
 	"strings"
 	"text/template"
 
@@ -18,10 +17,14 @@ type FunctionBlock struct {
 	System      *System      `gorm:"foreignKey:SystemID"`
 	Declaration string       `gorm:"type:TEXT"`
 	Call        string       `gorm:"type:TEXT"`
+	Init        string       `gorm:"type:TEXT"`
 	OMX         string       `gorm:"type:TEXT"`
 	OPC         string       `gorm:"type:TEXT"`
 	CdsType     string       `gorm:"size:50"`
 	Primary     bool         `gorm:"not null;default:false"`
+	Software    bool         `gorm:"not null;default:false"` // Объявлен в листе FB, без сигналов
+	Wiring      string       `gorm:"type:TEXT"`              // Явное подключение входов/выходов (лист FB)
+	Bindings    string       `gorm:"type:TEXT"`              // Ручные привязки сигналов (лист FB)
 	Equipment   string       `gorm:"size:50"`
 	NodeID      *uint        `gorm:"index"`
 	NodeRef     string       `gorm:"size:255"`
@@ -32,9 +35,13 @@ type FunctionBlock struct {
 	Description string       `gorm:"type:TEXT"`
 	Name        string       `gorm:"size:255"`
 	Comment     string       `gorm:"type:TEXT"`
+	Signal      *Signal      `gorm:"-"` // Исходный сигнал первичного ФБ (не хранится)
+	// Links - подключение из Wiring с разрешенными ссылками на ФБ (не хранится)
+	Links map[string]string `gorm:"-"`
 }
 
 type FBCallParams struct {
+	FB      *FunctionBlock
 	Tag     string
 	CdsType string
 	Address string
@@ -42,10 +49,20 @@ type FBCallParams struct {
 	Node    string
 	In      IOPair
 	Out     IOPair
+	// Signal - исходный сигнал первичного ФБ (AI, DI, ...), nil для составных ФБ
+	Signal *Signal
+	// Signals - сигналы переменных ФБ по FuncAttr
+	Signals map[string]*Signal
+	// InSignals и OutSignals - сигналы по именам входов/выходов ФБ
+	InSignals  IOSignals
+	OutSignals IOSignals
 }
 
 type IOPair map[string]string
 
+// IOSignals сопоставляет имя входа/выхода ФБ с привязанным сигналом
+type IOSignals map[string]*Signal
+
 type OPCItem struct {
 	Binding    string
 	NodePath   string
@@ -85,6 +102,15 @@ func ProcessIOPair(pairIn, pairOut map[string]string, fb *FunctionBlock) (IOPair
 		}
 	}
 	for _, v := range fb.Variables {
+		if v.IsLink() {
+			if v.Direction == "output" {
+				outputs[v.FuncAttr] = v.LinkRef()
+			} else {
+				inputs[v.FuncAttr] = v.LinkRef()
+			}
+			continue
+		}
+		signalTag := v.SourceTag()
 		switch v.Direction {
 		case "input":
 			for lhs, rhs := range pairIn {
@@ -94,9 +120,9 @@ func ProcessIOPair(pairIn, pairOut map[string]string, fb *FunctionBlock) (IOPair
 				parts := strings.Split(rhs, ".")
 				if v.FuncAttr == parts[0] {
 					if len(parts) > 1 {
-						inputs[lhs] = v.CdsType + "." + v.SignalTag + "." + parts[1]
+						inputs[lhs] = v.CdsType + "." + signalTag + "." + parts[1]
 					} else {
-						inputs[lhs] = v.CdsType + "." + v.SignalTag
+						inputs[lhs] = v.CdsType + "." + signalTag
 					}
 				}
 			}
@@ -108,9 +134,9 @@ func ProcessIOPair(pairIn, pairOut map[string]string, fb *FunctionBlock) (IOPair
 				parts := strings.Split(rhs, ".")
 				if v.FuncAttr == parts[0] {
 					if len(parts) > 1 {
-						outputs[lhs] = v.CdsType + "." + v.SignalTag + parts[1]
+						outputs[lhs] = v.CdsType + "." + signalTag + parts[1]
 					} else {
-						outputs[lhs] = v.CdsType + "." + v.SignalTag
+						outputs[lhs] = v.CdsType + "." + signalTag
 					}
 				}
 			}
@@ -118,16 +144,61 @@ func ProcessIOPair(pairIn, pairOut map[string]string, fb *FunctionBlock) (IOPair
 	}
 	return inputs, outputs
 }
-func (fb *FunctionBlock) GenerateSTCode(fbTemplate string, defaultInputs, defaultOutputs map[string]string) (string, error) {
+
+// ProcessIOSignals сопоставляет входы/выходы ФБ с сигналами его переменных
+// по тем же правилам, что и ProcessIOPair
+func ProcessIOSignals(pairIn, pairOut map[string]string, fb *FunctionBlock) (IOSignals, IOSignals) {
+	inputs := make(IOSignals)
+	outputs := make(IOSignals)
+	if fb.Signal != nil {
+		for lhs, rhs := range pairIn {
+			if rhs == "address" {
+				inputs[lhs] = fb.Signal
+			}
+		}
+		for lhs, rhs := range pairOut {
+			if rhs == "address" {
+				outputs[lhs] = fb.Signal
+			}
+		}
+	}
+	for i := range fb.Variables {
+		v := &fb.Variables[i]
+		if v.IsLink() {
+			continue
+		}
+		pairs, target := pairIn, inputs
+		if v.Direction == "output" {
+			pairs, target = pairOut, outputs
+		}
+		for lhs, rhs := range pairs {
+			if v.FuncAttr == strings.Split(rhs, ".")[0] {
+				target[lhs] = &v.Signal
+			}
+		}
+	}
+	return inputs, outputs
+}
+
+// CallParams подготавливает данные для шаблонов вызова и инициализации ФБ
+func (fb *FunctionBlock) CallParams(defaultInputs, defaultOutputs map[string]string) FBCallParams {
 	// Разделяем переменные на входы и выходы
 	inputs, outputs := ProcessIOPair(defaultInputs, defaultOutputs, fb)
+	inSignals, outSignals := ProcessIOSignals(defaultInputs, defaultOutputs, fb)
+
+	signals := make(map[string]*Signal)
+	for i := range fb.Variables {
+		if !fb.Variables[i].IsLink() {
+			signals[fb.Variables[i].FuncAttr] = &fb.Variables[i].Signal
+		}
+	}
 
 	var nodeName string
 	if fb.Node != nil {
 		nodeName = fb.Node.Name
 	}
-	// Подготавливаем данные для шаблона
-	data := FBCallParams{
+	return FBCallParams{
+		FB:      fb,
 		Tag:     fb.Tag,
 		CdsType: fb.CdsType,
 		Address: fb.Address,
@@ -135,9 +206,22 @@ func (fb *FunctionBlock) GenerateSTCode(fbTemplate string, defaultInputs, defaul
 		Node:    nodeName,
 		In:      inputs,
 		Out:     outputs,
+
+		Signal:     fb.Signal,
+		Signals:    signals,
+		InSignals:  inSignals,
+		OutSignals: outSignals,
 	}
+}
+
+func (fb *FunctionBlock) GenerateSTCode(fbTemplate string, defaultInputs, defaultOutputs, pinDefaults map[string]string) (string, error) {
+	// Подготавливаем данные для шаблона
+	data := fb.CallParams(defaultInputs, defaultOutputs)
+	data.applyDefaults(defaultInputs, defaultOutputs, pinDefaults)
+	data.applyLinks(defaultInputs, defaultOutputs, fb.Links)
+
 	// Создаем и выполняем шаблон
-	tmpl, err := template.New("fbCall").Parse(fbTemplate)
+	tmpl, err := newTemplate("fbCall").Parse(fbTemplate)
 	if err != nil {
 		return "", err
 	}
@@ -175,7 +259,7 @@ func (fb *FunctionBlock) GenerateOMX(tmplBase string, attributes map[string]stri
 		tmplWithAttrs += fmt.Sprintf("<attribute type=\"%s\" value=\"{{%s}}\"></attribute>\n", k, v)
 	}
 	tmplWithAttrs += `</object>`
-	tmpl, err := template.New("attr").Parse(tmplWithAttrs)
+	tmpl, err := newTemplate("attr").Parse(tmplWithAttrs)
 	if err != nil {
 		return "", err
 	}
@@ -242,7 +326,7 @@ func (fb *FunctionBlock) GenerateOPC(mapping OPCTemplate) (string, error) {
 	return buf.String(), nil
 }
 func executeTemplate(tmplStr string, data interface{}, funcs ...template.FuncMap) (string, error) {
-	tmpl := template.New("")
+	tmpl := newTemplate("")
 	if len(funcs) > 0 {
 		tmpl = tmpl.Funcs(funcs[0])
 	}
@@ -260,13 +344,46 @@ func executeTemplate(tmplStr string, data interface{}, funcs ...template.FuncMap
 
 type FBVariable struct {
 	gorm.Model
-	FBID      uint   `gorm:"index"`
-	Direction string `gorm:"size:10;check:direction IN ('input', 'output')"`
-	CdsType   string `gorm:"size:30"`
-	Signal    Signal `gorm:"foreignKey:SignalTag;references:Tag"`
-	Address   string `gorm:"size:255"`
-	SignalTag string `gorm:"size:255;not null"`
-	FuncAttr  string `gorm:"size:100;not null"` // Часть после последнего '_' в Tag сигнала
+	FBID      uint    `gorm:"index"`
+	Direction string  `gorm:"size:10;check:direction IN ('input', 'output')"`
+	CdsType   string  `gorm:"size:30"`
+	Signal    Signal  `gorm:"foreignKey:SignalTag;references:Tag"`
+	Address   string  `gorm:"size:255"`
+	SignalTag *string `gorm:"size:255"`          // nil для связи ФБ-ФБ
+	FuncAttr  string  `gorm:"size:100;not null"` // Часть после последнего '_' в Tag сигнала; для связи ФБ-ФБ - вход/выход ФБ
+	// Связь ФБ-ФБ: вход ФБ подключен к выходу SourcePin ФБ-источника
+	// (для направления output - выход ФБ пишется во вход ФБ-источника)
+	SourceFBID *uint          `gorm:"index"`
+	SourceFB   *FunctionBlock `gorm:"foreignKey:SourceFBID"`
+	SourcePin  string         `gorm:"size:100"`
+}
+
+// IsLink сообщает, что переменная связывает ФБ с другим ФБ, а не с сигналом
+func (v *FBVariable) IsLink() bool {
+	return v.SourceFBID != nil
+}
+
+// LinkRef возвращает выражение ST для связи ФБ-ФБ: "MTR.M101.q_xOn"
+func (v *FBVariable) LinkRef() string {
+	if v.SourceFB == nil {
+		return ""
+	}
+	ref := v.SourceFB.CdsType + "." + v.SourceFB.Tag
+	if v.SourcePin != "" {
+		ref += "." + v.SourcePin
+	}
+	return ref
+}
+
+// SourceTag возвращает тэг источника переменной: сигнала или ФБ
+func (v *FBVariable) SourceTag() string {
+	if v.SignalTag != nil {
+		return *v.SignalTag
+	}
+	if v.SourceFB != nil {
+		return v.SourceFB.Tag
+	}
+	return ""
 }
 
 // ParseFBInfo разбирает тэг сигнала на имя FB и атрибут
@@ -277,26 +394,11 @@ func ParseFBInfo(signalTag string) (fbTag, funcAttr string, ok bool) {
 	}
 	return strings.Join(parts[:len(parts)-1], "_"), parts[len(parts)-1], true
 }
-func decrementString(channel string) (string, error) {
-	num, err := strconv.Atoi(channel)
-	if err != nil {
-		return "", fmt.Errorf("invalid channel number: %v", err)
-	}
-	return strconv.Itoa(num - 1), nil
-}
-func formatNumber(input string, length int) string {
-	if len(input) >= length {
-		return input
-	}
-
-	// Дополняем нулями слева
-	return strings.Repeat("0", length-len(input)) + input
-}
 
-// ParseFBFromSignal создает/обновляет FunctionBlock из сигнала
-func ParseFBFromSignal(signal Signal, direction string, addressTmpl string) (*FunctionBlock, *FBVariable, error) {
-	fbTag, funcAttr, _ := ParseFBInfo(signal.Tag)
-	addr, err := executeTemplate(addressTmpl, signal, template.FuncMap{"decrement": decrementString, "format_number": formatNumber})
+// ParseFBFromSignal создает/обновляет FunctionBlock из сигнала.
+// fbTag и funcAttr - результат разбора тэга сигнала (ParseFBInfo или FBTagRule)
+func ParseFBFromSignal(signal Signal, fbTag, funcAttr, direction string, addressTmpl string) (*FunctionBlock, *FBVariable, error) {
+	addr, err := executeTemplate(addressTmpl, signal)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ошибка выполнения шаблона для сигнала %s: %v", signal.Tag, err)
 	}
@@ -311,7 +413,7 @@ func ParseFBFromSignal(signal Signal, direction string, addressTmpl string) (*Fu
 	}
 
 	variable := &FBVariable{
-		SignalTag: signal.Tag,
+		SignalTag: &signal.Tag,
 		Address:   addr,
 		CdsType:   signal.SignalType,
 		FuncAttr:  funcAttr,
@@ -322,7 +424,7 @@ func ParseFBFromSignal(signal Signal, direction string, addressTmpl string) (*Fu
 }
 
 func UpdateAddress(signal Signal, addressTmpl string) (string, error) {
-	addr, err := executeTemplate(addressTmpl, signal, template.FuncMap{"decrement": decrementString, "format_number": formatNumber})
+	addr, err := executeTemplate(addressTmpl, signal)
 	if err != nil {
 		return signal.Address, fmt.Errorf("ошибка выполнения шаблона для сигнала %s: %v", signal.Tag, err)
 	} else {
@@ -331,7 +433,7 @@ func UpdateAddress(signal Signal, addressTmpl string) (string, error) {
 }
 
 func ParseFromSignal(signal Signal, addressTmpl string) (*FunctionBlock, error) {
-	addr, err := executeTemplate(addressTmpl, signal, template.FuncMap{"decrement": decrementString, "format_number": formatNumber})
+	addr, err := executeTemplate(addressTmpl, signal)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения шаблона для сигнала %s: %v", signal.Tag, err)
 	}
@@ -346,6 +448,7 @@ func ParseFromSignal(signal Signal, addressTmpl string) (*FunctionBlock, error)
 		Equipment: signal.Equipment,
 		Address:   addr,
 		Name:      signal.Name,
+		Signal:    &signal,
 		Comment: fmt.Sprintf("%s\n%s\nproduct: %s; crate: %s; module: %s; channel: %s",
 			signal.NodeRef, signal.Name, signal.ProductRef, signal.Crate, signal.Module, signal.Channel),
 	}
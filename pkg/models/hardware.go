@@ -0,0 +1,212 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Состояние канала в отчете по оборудованию
+const (
+	ChannelUsed      = "занят"
+	ChannelFree      = "свободен"
+	ChannelDuplicate = "дубль"
+	ChannelMismatch  = "несоответствие типа"
+	ChannelOverflow  = "вне диапазона"
+)
+
+// HardwareModule - модуль ввода/вывода в крейте и распределение его каналов
+type HardwareModule struct {
+	Product    string            `json:"product"`
+	Crate      string            `json:"crate"`
+	Module     string            `json:"module"`
+	Type       string            `json:"type"`                 // Тип сигналов модуля: из каталога, иначе преобладающий
+	ModuleType string            `json:"moduleType,omitempty"` // Тип модуля из каталога
+	Capacity   int               `json:"capacity"`             // Число каналов по каталогу, иначе наибольший занятый номер
+	Used       int               `json:"used"`
+	Free       []int             `json:"free"`
+	Channels   []HardwareChannel `json:"channels"`
+	Mismatches []string          `json:"mismatches,omitempty"` // Сигналы, не совпадающие по типу с модулем
+	Duplicates []string          `json:"duplicates,omitempty"` // Каналы с несколькими сигналами
+	Overflow   []string          `json:"overflow,omitempty"`   // Каналы за пределами числа каналов модуля
+}
+
+// HardwareChannel - канал модуля и подключенные к нему сигналы
+type HardwareChannel struct {
+	Channel string   `json:"channel"`
+	Signals []string `json:"signals"`
+	Types   []string `json:"types"`
+	Status  string   `json:"status"`
+}
+
+// SheetHardware - строка листа "Hardware"
+type SheetHardware struct {
+	Product    string `gsheets:"product"`
+	Crate      string `gsheets:"crate"`
+	Module     string `gsheets:"module"`
+	ModuleType string `gsheets:"module_type"`
+	Channel    string `gsheets:"channel"`
+	Signal     string `gsheets:"signal"`
+	SignalType string `gsheets:"signal_type"`
+	Status     string `gsheets:"status"`
+}
+
+// BuildHardwareReport группирует сигналы по крейтам и модулям и проверяет
+// распределение каналов: занятость, свободные каналы, дубли и несоответствие типа.
+// Тип сигналов модуля берется из каталога catalogue (может быть nil), если он там задан
+func BuildHardwareReport(signals []Signal, catalogue *ModuleCatalogue) []HardwareModule {
+	modules := make(map[[3]string]*HardwareModule)
+	channels := make(map[[3]string]map[string]*HardwareChannel)
+	var keys [][3]string
+
+	for i := range signals {
+		s := &signals[i]
+		if strings.TrimSpace(s.Module) == "" {
+			continue
+		}
+		product := productTag(s)
+		key := [3]string{product, s.Crate, s.Module}
+		if _, ok := modules[key]; !ok {
+			modules[key] = &HardwareModule{
+				Product:    product,
+				Crate:      s.Crate,
+				Module:     s.Module,
+				ModuleType: s.ModuleType,
+				Capacity:   s.ModuleChannels,
+			}
+			channels[key] = make(map[string]*HardwareChannel)
+			keys = append(keys, key)
+		}
+		ch, ok := channels[key][s.Channel]
+		if !ok {
+			ch = &HardwareChannel{Channel: s.Channel}
+			channels[key][s.Channel] = ch
+		}
+		ch.Signals = append(ch.Signals, s.Tag)
+		ch.Types = append(ch.Types, s.SignalType)
+	}
+
+	sort.Slice(keys, func(a, b int) bool {
+		for i := range keys[a] {
+			if keys[a][i] != keys[b][i] {
+				return naturalLess(keys[a][i], keys[b][i])
+			}
+		}
+		return false
+	})
+
+	report := make([]HardwareModule, 0, len(keys))
+	for _, key := range keys {
+		m := modules[key]
+		for _, ch := range channels[key] {
+			m.Channels = append(m.Channels, *ch)
+		}
+		if spec := catalogue.Resolve(m.Module); spec != nil && spec.SignalType != "" {
+			m.Type = spec.SignalType
+		} else {
+			m.Type = dominantType(m.Channels)
+		}
+		m.check()
+		report = append(report, *m)
+	}
+	return report
+}
+
+// check заполняет статусы каналов, свободные каналы, дубли и несоответствия типа
+func (m *HardwareModule) check() {
+	used := make(map[int]bool)
+	fixed := m.Capacity > 0
+	m.Used, m.Free, m.Mismatches, m.Duplicates, m.Overflow = 0, nil, nil, nil, nil
+	for i := range m.Channels {
+		ch := &m.Channels[i]
+		ch.Status = ChannelUsed
+		if n, err := strconv.Atoi(strings.TrimSpace(ch.Channel)); err == nil {
+			used[n] = true
+			if fixed && (n < 1 || n > m.Capacity) {
+				ch.Status = ChannelOverflow
+				m.Overflow = append(m.Overflow, ch.Channel)
+			} else if n > m.Capacity {
+				m.Capacity = n
+			}
+		}
+		if len(ch.Signals) > 1 {
+			if ch.Status == ChannelUsed {
+				ch.Status = ChannelDuplicate
+			}
+			m.Duplicates = append(m.Duplicates, ch.Channel)
+		}
+		for j, t := range ch.Types {
+			if m.Type != "" && t != m.Type {
+				m.Mismatches = append(m.Mismatches, ch.Signals[j])
+				if ch.Status == ChannelUsed {
+					ch.Status = ChannelMismatch
+				}
+			}
+		}
+	}
+	m.Used = len(m.Channels)
+	for n := 1; n <= m.Capacity; n++ {
+		if !used[n] {
+			m.Free = append(m.Free, n)
+		}
+	}
+	sort.Slice(m.Channels, func(a, b int) bool {
+		return naturalLess(m.Channels[a].Channel, m.Channels[b].Channel)
+	})
+}
+
+// SheetRows возвращает строки листа "Hardware": по строке на сигнал и свободный канал
+func (m *HardwareModule) SheetRows() []SheetHardware {
+	var rows []SheetHardware
+	row := func(channel, signal, signalType, status string) SheetHardware {
+		return SheetHardware{
+			Product:    m.Product,
+			Crate:      m.Crate,
+			Module:     m.Module,
+			ModuleType: m.ModuleType,
+			Channel:    channel,
+			Signal:     signal,
+			SignalType: signalType,
+			Status:     status,
+		}
+	}
+	for _, ch := range m.Channels {
+		for i, tag := range ch.Signals {
+			rows = append(rows, row(ch.Channel, tag, ch.Types[i], ch.Status))
+		}
+	}
+	for _, n := range m.Free {
+		rows = append(rows, row(strconv.Itoa(n), "", "", ChannelFree))
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		return naturalLess(rows[a].Channel, rows[b].Channel)
+	})
+	return rows
+}
+
+// dominantType возвращает наиболее частый тип сигналов модуля
+func dominantType(channels []HardwareChannel) string {
+	counts := make(map[string]int)
+	for _, ch := range channels {
+		for _, t := range ch.Types {
+			counts[t]++
+		}
+	}
+	var best string
+	for t, n := range counts {
+		if n > counts[best] || (n == counts[best] && t < best) {
+			best = t
+		}
+	}
+	return best
+}
+
+// naturalLess сравнивает строки с учетом числового значения ("2" < "10")
+func naturalLess(a, b string) bool {
+	x, errA := strconv.Atoi(strings.TrimSpace(a))
+	y, errB := strconv.Atoi(strings.TrimSpace(b))
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
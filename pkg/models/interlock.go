@@ -0,0 +1,194 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SheetInterlock - строка листа блокировок
+type SheetInterlock struct {
+	Tag       string `gsheets:"id"`
+	Cause     string `gsheets:"cause"`     // Тэг сигнала или ФБ, для ФБ - "FB.выход"
+	Condition string `gsheets:"condition"` // "> 80", "= FALSE" или выражение с {cause}
+	Effect    string `gsheets:"effect"`    // Вход ФБ-исполнителя: "VLV101.i_xIlkClose"
+	Priority  string `gsheets:"priority"`
+	Bypass    string `gsheets:"bypass"` // Блокировку можно деблокировать
+	Comment   string `gsheets:"comment"`
+}
+
+// Interlock - блокировка: условие по сигналу/ФБ-причине воздействует на вход ФБ-исполнителя
+type Interlock struct {
+	gorm.Model
+	Tag            string         `gorm:"size:100;not null;uniqueIndex"`
+	SystemID       *uint          `gorm:"index"`
+	System         *System        `gorm:"foreignKey:SystemID"`
+	NodeID         *uint          `gorm:"index"` // Узел ФБ-исполнителя
+	Node           *Node          `gorm:"foreignKey:NodeID"`
+	CauseSignalTag *string        `gorm:"size:255"`
+	CauseSignal    *Signal        `gorm:"foreignKey:CauseSignalTag;references:Tag"`
+	CauseFBID      *uint          `gorm:"index"`
+	CauseFB        *FunctionBlock `gorm:"foreignKey:CauseFBID"`
+	CausePin       string         `gorm:"size:100"`
+	Condition      string         `gorm:"type:TEXT"`
+	EffectFBID     uint           `gorm:"index;not null"`
+	EffectFB       *FunctionBlock `gorm:"foreignKey:EffectFBID"`
+	EffectPin      string         `gorm:"size:100;not null"`
+	Priority       int            `gorm:"not null;default:0"` // Меньше - важнее
+	Bypass         bool           `gorm:"not null;default:false"`
+	Comment        string         `gorm:"type:TEXT"`
+}
+
+// ParsePriority разбирает приоритет блокировки; пустое значение - 0
+func (s SheetInterlock) ParsePriority() (int, error) {
+	p := strings.TrimSpace(s.Priority)
+	if p == "" {
+		return 0, nil
+	}
+	priority, err := strconv.Atoi(p)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q", s.Priority)
+	}
+	return priority, nil
+}
+
+// BypassEnabled сообщает, допускает ли блокировка деблокирование
+func (s SheetInterlock) BypassEnabled() bool {
+	b, _ := parseSheetBool(strings.TrimSpace(s.Bypass))
+	return b
+}
+
+var (
+	conditionOperator = regexp.MustCompile(`^(<>|<=|>=|=|<|>)`)
+	nonIdentChars     = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// CauseExpr возвращает выражение ST причины: "MTR.M101.q_xFault", "AI.TT101"
+func (i *Interlock) CauseExpr() string {
+	var expr string
+	switch {
+	case i.CauseFB != nil:
+		expr = i.CauseFB.CdsType + "." + i.CauseFB.Tag
+	case i.CauseSignal != nil:
+		expr = i.CauseSignal.SignalType + "." + i.CauseSignal.Tag
+	case i.CauseSignalTag != nil:
+		expr = *i.CauseSignalTag
+	}
+	if i.CausePin != "" {
+		expr += "." + i.CausePin
+	}
+	return expr
+}
+
+// EffectExpr возвращает выражение ST входа ФБ-исполнителя
+func (i *Interlock) EffectExpr() string {
+	if i.EffectFB == nil {
+		return ""
+	}
+	return i.EffectFB.CdsType + "." + i.EffectFB.Tag + "." + i.EffectPin
+}
+
+// BypassVar возвращает имя переменной деблокирования
+func (i *Interlock) BypassVar() string {
+	return "ILK_" + nonIdentChars.ReplaceAllString(i.Tag, "_") + "_BYP"
+}
+
+// ConditionExpr формирует условие срабатывания блокировки.
+// Пустое условие - причина как логическое значение; условие, начинающееся
+// с оператора сравнения, дополняет причину; иначе {cause} заменяется на причину.
+func (i *Interlock) ConditionExpr() string {
+	cause := i.CauseExpr()
+	cond := strings.TrimSpace(i.Condition)
+	var expr string
+	switch {
+	case cond == "":
+		expr = cause
+	case strings.Contains(cond, "{cause}"):
+		expr = strings.ReplaceAll(cond, "{cause}", cause)
+	case conditionOperator.MatchString(cond):
+		expr = cause + " " + cond
+	default:
+		expr = cond
+	}
+	if i.Bypass {
+		expr = "(" + expr + ") AND NOT " + i.BypassVar()
+	}
+	return expr
+}
+
+// GenerateInterlockST формирует логику блокировок узла: объявления переменных
+// деблокирования (как STDecl, без VAR/END_VAR) и присваивание входов
+// ФБ-исполнителей (OR условий в порядке приоритета)
+func GenerateInterlockST(node string, interlocks []Interlock) (decl, body string) {
+	if len(interlocks) == 0 {
+		return "", ""
+	}
+
+	byEffect := make(map[string][]*Interlock)
+	var effects []string
+	for idx := range interlocks {
+		ilk := &interlocks[idx]
+		effect := ilk.EffectExpr()
+		if _, ok := byEffect[effect]; !ok {
+			effects = append(effects, effect)
+		}
+		byEffect[effect] = append(byEffect[effect], ilk)
+	}
+	for _, list := range byEffect {
+		sort.SliceStable(list, func(a, b int) bool {
+			if list[a].Priority != list[b].Priority {
+				return list[a].Priority < list[b].Priority
+			}
+			return list[a].Tag < list[b].Tag
+		})
+	}
+	sort.SliceStable(effects, func(a, b int) bool {
+		pa, pb := byEffect[effects[a]][0].Priority, byEffect[effects[b]][0].Priority
+		if pa != pb {
+			return pa < pb
+		}
+		return effects[a] < effects[b]
+	})
+
+	var header string
+	if node != "" {
+		header = fmt.Sprintf("(* Блокировки: %s *)\n", stComment(node))
+	}
+
+	var declBuf bytes.Buffer
+	for _, effect := range effects {
+		for _, ilk := range byEffect[effect] {
+			if !ilk.Bypass {
+				continue
+			}
+			declBuf.WriteString(FormatVarDeclaration(ilk.BypassVar(), "BOOL", 4, 40))
+			if ilk.Comment != "" {
+				declBuf.WriteString(" (* " + stComment(ilk.Comment) + " *)")
+			}
+			declBuf.WriteString("\n")
+		}
+	}
+	if declBuf.Len() > 0 {
+		decl = header + declBuf.String()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, effect := range effects {
+		buf.WriteString("\n" + effect + " :=")
+		for n, ilk := range byEffect[effect] {
+			op := "\n    "
+			if n > 0 {
+				op = "\n    OR "
+			}
+			buf.WriteString(fmt.Sprintf("%s(%s) (* %s *)", op, ilk.ConditionExpr(), stComment(ilk.Tag)))
+		}
+		buf.WriteString(";\n")
+	}
+	return decl, buf.String()
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Типы регистров Modbus
+const (
+	ModbusCoil     = "coil"     // 0xxxx, чтение/запись бит
+	ModbusDiscrete = "discrete" // 1xxxx, чтение бит
+	ModbusInput    = "input"    // 3xxxx, чтение регистров
+	ModbusHolding  = "holding"  // 4xxxx, чтение/запись регистров
+)
+
+var modbusPrefixes = map[string]string{
+	"CO": ModbusCoil,
+	"DI": ModbusDiscrete,
+	"IR": ModbusInput,
+	"HR": ModbusHolding,
+}
+
+var modiconTypes = map[byte]string{
+	'0': ModbusCoil,
+	'1': ModbusDiscrete,
+	'3': ModbusInput,
+	'4': ModbusHolding,
+}
+
+// ModbusSettings - правила построения карты Modbus по типу сигнала
+type ModbusSettings struct {
+	RegisterTypes map[string]string // Тип сигнала -> тип регистра по умолчанию
+	DataTypes     map[string]string // Тип сигнала -> тип данных (bool, int16, uint16, int32, uint32, float32, float64)
+	RawMin        *float64          // Диапазон кода для целых типов (масштабирование RangeMin..RangeMax)
+	RawMax        *float64
+}
+
+// ModbusRegister - строка карты регистров
+type ModbusRegister struct {
+	Node        string `json:"node"`
+	System      string `json:"system"`
+	Address     int    `json:"address"` // Адрес протокола (с нуля)
+	Type        string `json:"type"`
+	DataType    string `json:"dataType"`
+	Length      int    `json:"length"` // Число регистров (бит для coil/discrete)
+	Tag         string `json:"tag"`
+	Description string `json:"description"`
+	Unit        string `json:"unit,omitempty"`
+	ScaleMin    string `json:"scaleMin,omitempty"`
+	ScaleMax    string `json:"scaleMax,omitempty"`
+	RawMin      string `json:"rawMin,omitempty"`
+	RawMax      string `json:"rawMax,omitempty"`
+}
+
+// ModbusIssue - ошибка карты Modbus: неверный адрес, дубль или перекрытие регистров
+type ModbusIssue struct {
+	System  string   `json:"system,omitempty"`
+	Node    string   `json:"node"`
+	Type    string   `json:"type,omitempty"`
+	Address int      `json:"address"`
+	Tags    []string `json:"tags"`
+	Reason  string   `json:"reason"`
+}
+
+// ParseModbusAddr разбирает адрес Modbus сигнала:
+//
+//	HR:100, IR:5    — префикс типа (CO, DI, IR, HR) и адрес протокола
+//	100             — адрес протокола, тип регистра по умолчанию
+//	40001, 300010   — нотация Modicon (первая цифра - тип регистра, адрес с единицы),
+//	                  только если тип регистра по умолчанию не задан
+func ParseModbusAddr(addr, defaultType string) (string, int, error) {
+	addr = strings.TrimSpace(addr)
+	if prefix, rest, ok := strings.Cut(addr, ":"); ok {
+		regType, known := modbusPrefixes[strings.ToUpper(strings.TrimSpace(prefix))]
+		if !known {
+			return "", 0, fmt.Errorf("unknown register prefix %q", prefix)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(rest))
+		if err != nil || n < 0 {
+			return "", 0, fmt.Errorf("invalid modbus address %q", addr)
+		}
+		return regType, n, nil
+	}
+
+	n, err := strconv.Atoi(addr)
+	if err != nil || n < 0 {
+		return "", 0, fmt.Errorf("invalid modbus address %q", addr)
+	}
+	if defaultType != "" {
+		return defaultType, n, nil
+	}
+	if len(addr) >= 5 {
+		if regType, ok := modiconTypes[addr[0]]; ok {
+			offset, _ := strconv.Atoi(addr[1:])
+			if offset < 1 {
+				return "", 0, fmt.Errorf("invalid modbus address %q", addr)
+			}
+			return regType, offset - 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("register type is not set for address %q", addr)
+}
+
+// modbusLength возвращает число регистров для типа данных
+func modbusLength(dataType string) int {
+	switch dataType {
+	case "int32", "uint32", "float32":
+		return 2
+	case "float64", "int64", "uint64":
+		return 4
+	default:
+		return 1
+	}
+}
+
+// BuildModbusMap формирует карту регистров из сигналов с адресом Modbus
+func BuildModbusMap(signals []Signal, settings ModbusSettings) ([]ModbusRegister, []ModbusIssue) {
+	var registers []ModbusRegister
+	var issues []ModbusIssue
+	for i := range signals {
+		s := &signals[i]
+		if strings.TrimSpace(s.ModbusAddr) == "" {
+			continue
+		}
+		regType, addr, err := ParseModbusAddr(s.ModbusAddr, settings.RegisterTypes[s.SignalType])
+		if err != nil {
+			issues = append(issues, ModbusIssue{System: systemName(s), Node: nodeName(s), Tags: []string{s.Tag}, Reason: err.Error()})
+			continue
+		}
+
+		dataType := settings.DataTypes[s.SignalType]
+		if regType == ModbusCoil || regType == ModbusDiscrete || dataType == "" {
+			dataType = "bool"
+		}
+		description := s.Name
+		if description == "" {
+			description = s.Comment
+		}
+		reg := ModbusRegister{
+			Node:        nodeName(s),
+			System:      systemName(s),
+			Address:     addr,
+			Type:        regType,
+			DataType:    dataType,
+			Length:      modbusLength(dataType),
+			Tag:         s.Tag,
+			Description: description,
+		}
+		if s.Unit != nil {
+			reg.Unit = *s.Unit
+		}
+		if dataType != "bool" && s.RangeMin != nil && s.RangeMax != nil {
+			reg.ScaleMin = strconv.FormatFloat(*s.RangeMin, 'f', -1, 64)
+			reg.ScaleMax = strconv.FormatFloat(*s.RangeMax, 'f', -1, 64)
+			if !strings.HasPrefix(dataType, "float") && settings.RawMin != nil && settings.RawMax != nil {
+				reg.RawMin = strconv.FormatFloat(*settings.RawMin, 'f', -1, 64)
+				reg.RawMax = strconv.FormatFloat(*settings.RawMax, 'f', -1, 64)
+			}
+		}
+		registers = append(registers, reg)
+	}
+
+	sort.SliceStable(registers, func(a, b int) bool {
+		x, y := registers[a], registers[b]
+		if x.System != y.System {
+			return x.System < y.System
+		}
+		if x.Node != y.Node {
+			return x.Node < y.Node
+		}
+		if x.Type != y.Type {
+			return x.Type < y.Type
+		}
+		return x.Address < y.Address
+	})
+	return registers, issues
+}
+
+// FindModbusConflicts ищет дубли и перекрытия регистров в пределах системы, узла и типа регистра.
+// registers должны быть упорядочены, как возвращает BuildModbusMap.
+func FindModbusConflicts(registers []ModbusRegister) []ModbusIssue {
+	var issues []ModbusIssue
+	for i := 1; i < len(registers); i++ {
+		cur := registers[i]
+		// Сравниваем с предыдущими регистрами, которые могут доходить до текущего адреса
+		for j := i - 1; j >= 0; j-- {
+			prev := registers[j]
+			if prev.System != cur.System || prev.Node != cur.Node || prev.Type != cur.Type {
+				break
+			}
+			if prev.Address+prev.Length <= cur.Address {
+				// Регистры упорядочены по адресу; длина не больше 4
+				if cur.Address-prev.Address >= 4 {
+					break
+				}
+				continue
+			}
+			reason := fmt.Sprintf("registers overlap: %s occupies %d..%d", prev.Tag, prev.Address, prev.Address+prev.Length-1)
+			if prev.Address == cur.Address {
+				reason = "duplicate register address"
+			}
+			issues = append(issues, ModbusIssue{
+				System:  cur.System,
+				Node:    cur.Node,
+				Type:    cur.Type,
+				Address: cur.Address,
+				Tags:    []string{prev.Tag, cur.Tag},
+				Reason:  reason,
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ModuleSpec - тип модуля ввода/вывода из каталога
+type ModuleSpec struct {
+	Code       string `json:"code"`
+	Match      string `json:"match,omitempty"` // Регулярное выражение для Signal.Module, по умолчанию - совпадение с Code
+	Channels   int    `json:"channels"`        // Число каналов, нумерация с 1
+	SignalType string `json:"signalType,omitempty"`
+	Addressing string `json:"addressing,omitempty"` // Схема адресации, доступна в шаблоне адреса как .ModuleAddressing
+	re         *regexp.Regexp
+}
+
+// SheetModule - строка листа каталога модулей
+type SheetModule struct {
+	Code       string `gsheets:"code"`
+	Match      string `gsheets:"match"`
+	Channels   string `gsheets:"channels"`
+	SignalType string `gsheets:"signal_type"`
+	Addressing string `gsheets:"addressing"`
+}
+
+// ModuleIssue - ошибка привязки сигнала к модулю каталога
+type ModuleIssue struct {
+	SignalTag string `json:"signalTag"`
+	Module    string `json:"module"`
+	Channel   string `json:"channel"`
+	Reason    string `json:"reason"`
+}
+
+// ModuleCatalogue - каталог типов модулей; первый подходящий тип используется для модуля
+type ModuleCatalogue struct {
+	specs []*ModuleSpec
+}
+
+// ToSpec преобразует строку листа в тип модуля
+func (m SheetModule) ToSpec() (ModuleSpec, error) {
+	channels, err := strconv.Atoi(strings.TrimSpace(m.Channels))
+	if err != nil {
+		return ModuleSpec{}, fmt.Errorf("module %s: invalid channel count %q", m.Code, m.Channels)
+	}
+	return ModuleSpec{
+		Code:       strings.TrimSpace(m.Code),
+		Match:      strings.TrimSpace(m.Match),
+		Channels:   channels,
+		SignalType: strings.TrimSpace(m.SignalType),
+		Addressing: strings.TrimSpace(m.Addressing),
+	}, nil
+}
+
+// NewModuleCatalogue создает каталог и компилирует выражения Match
+func NewModuleCatalogue(specs []ModuleSpec) (*ModuleCatalogue, error) {
+	c := &ModuleCatalogue{}
+	for _, spec := range specs {
+		spec := spec
+		if spec.Code == "" {
+			return nil, fmt.Errorf("module spec without code")
+		}
+		if spec.Channels <= 0 {
+			return nil, fmt.Errorf("module %s: channel count must be positive", spec.Code)
+		}
+		if spec.Match != "" {
+			re, err := regexp.Compile(spec.Match)
+			if err != nil {
+				return nil, fmt.Errorf("module %s: invalid match %q: %w", spec.Code, spec.Match, err)
+			}
+			spec.re = re
+		}
+		c.specs = append(c.specs, &spec)
+	}
+	return c, nil
+}
+
+// Specs возвращает типы модулей каталога
+func (c *ModuleCatalogue) Specs() []ModuleSpec {
+	specs := make([]ModuleSpec, 0, len(c.specs))
+	for _, spec := range c.specs {
+		specs = append(specs, *spec)
+	}
+	return specs
+}
+
+// Resolve находит тип модуля по значению Signal.Module
+func (c *ModuleCatalogue) Resolve(module string) *ModuleSpec {
+	if c == nil {
+		return nil
+	}
+	module = strings.TrimSpace(module)
+	for _, spec := range c.specs {
+		if spec.re != nil {
+			if spec.re.MatchString(module) {
+				return spec
+			}
+		} else if strings.EqualFold(module, spec.Code) {
+			return spec
+		}
+	}
+	return nil
+}
+
+// Apply привязывает сигнал к типу модуля (заполняет ModuleType, ModuleChannels,
+// ModuleAddressing) и проверяет тип сигнала и номер канала; возвращает все найденные ошибки
+func (c *ModuleCatalogue) Apply(s *Signal) []ModuleIssue {
+	if c == nil || len(c.specs) == 0 || strings.TrimSpace(s.Module) == "" {
+		return nil
+	}
+	var issues []ModuleIssue
+	issue := func(format string, args ...interface{}) {
+		issues = append(issues, ModuleIssue{SignalTag: s.Tag, Module: s.Module, Channel: s.Channel, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	spec := c.Resolve(s.Module)
+	if spec == nil {
+		s.ModuleType, s.ModuleChannels, s.ModuleAddressing = "", 0, ""
+		issue("module is not in catalogue")
+		return issues
+	}
+	s.ModuleType, s.ModuleChannels, s.ModuleAddressing = spec.Code, spec.Channels, spec.Addressing
+
+	if spec.SignalType != "" && s.SignalType != spec.SignalType {
+		issue("%s signal on %s module %s", s.SignalType, spec.SignalType, spec.Code)
+	}
+	channel, err := strconv.Atoi(strings.TrimSpace(s.Channel))
+	if err != nil {
+		issue("invalid channel number %q", s.Channel)
+	} else if channel < 1 || channel > spec.Channels {
+		issue("channel %d is beyond capacity %d of module %s", channel, spec.Channels, spec.Code)
+	}
+	return issues
+}
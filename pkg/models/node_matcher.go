@@ -0,0 +1,167 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Стратегии сопоставления узлов
+const (
+	MatchPgTrgm     = "pg_trgm"    // similarity() в PostgreSQL (по умолчанию)
+	MatchExact      = "exact"      // Точное совпадение
+	MatchNormalized = "normalized" // Совпадение после нормализации
+	MatchToken      = "token"      // Доля общих слов
+	MatchTrigram    = "trigram"    // Триграммы, как в pg_trgm, после нормализации
+)
+
+// NodeMatcher оценивает сходство текста узла из листа с именем узла: 0 - нет, 1 - совпадение
+type NodeMatcher interface {
+	Name() string
+	Score(ref, name string) float64
+}
+
+// NewNodeMatcher возвращает сопоставитель по имени стратегии
+func NewNodeMatcher(strategy string) (NodeMatcher, error) {
+	switch strategy {
+	case MatchExact:
+		return ExactMatcher{}, nil
+	case MatchNormalized:
+		return NormalizedMatcher{}, nil
+	case MatchToken:
+		return TokenMatcher{}, nil
+	case MatchTrigram:
+		return TrigramMatcher{}, nil
+	}
+	return nil, fmt.Errorf("unknown node matching strategy %q", strategy)
+}
+
+// RankNodes возвращает до limit узлов с ненулевым сходством, по убыванию сходства
+func RankNodes(matcher NodeMatcher, ref string, nodes []Node, limit int) []NodeCandidate {
+	var candidates []NodeCandidate
+	for _, node := range nodes {
+		if score := matcher.Score(ref, node.Name); score > 0 {
+			candidates = append(candidates, NodeCandidate{ID: node.ID, Name: node.Name, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// ExactMatcher - совпадение строк без изменений
+type ExactMatcher struct{}
+
+func (ExactMatcher) Name() string { return MatchExact }
+
+func (ExactMatcher) Score(ref, name string) float64 {
+	if ref == name {
+		return 1
+	}
+	return 0
+}
+
+// NormalizedMatcher - совпадение без учета регистра, кириллических/латинских двойников,
+// пробелов и знаков препинания ("Насос Н-1" = "hacoc h1")
+type NormalizedMatcher struct{}
+
+func (NormalizedMatcher) Name() string { return MatchNormalized }
+
+func (NormalizedMatcher) Score(ref, name string) float64 {
+	a, b := strings.Join(NormalizeName(ref), ""), strings.Join(NormalizeName(name), "")
+	if a != "" && a == b {
+		return 1
+	}
+	return 0
+}
+
+// TokenMatcher - доля общих слов после нормализации (коэффициент Жаккара)
+type TokenMatcher struct{}
+
+func (TokenMatcher) Name() string { return MatchToken }
+
+func (TokenMatcher) Score(ref, name string) float64 {
+	return jaccard(toSet(NormalizeName(ref)), toSet(NormalizeName(name)))
+}
+
+// TrigramMatcher - сходство по триграммам слов, как similarity() в pg_trgm,
+// после нормализации
+type TrigramMatcher struct{}
+
+func (TrigramMatcher) Name() string { return MatchTrigram }
+
+func (TrigramMatcher) Score(ref, name string) float64 {
+	return jaccard(trigrams(NormalizeName(ref)), trigrams(NormalizeName(name)))
+}
+
+// homoglyphs - кириллические буквы, совпадающие по начертанию с латинскими
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'і': 'i', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x',
+}
+
+// NormalizeName разбивает имя на слова в нижнем регистре: кириллические двойники
+// заменяются латинскими, пробелы и знаки препинания считаются разделителями
+func NormalizeName(s string) []string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if latin, ok := homoglyphs[r]; ok {
+			r = latin
+		}
+		word.WriteRune(r)
+	}
+	flush()
+	return words
+}
+
+// trigrams возвращает триграммы слов, дополненных пробелами как в pg_trgm ("  w", " wo", "wo ")
+func trigrams(words []string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range words {
+		runes := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+func toSet(words []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
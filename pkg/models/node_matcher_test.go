@@ -0,0 +1,139 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"only punctuation", " -/.", nil},
+		{"lower case", "PUMP 1", []string{"pump", "1"}},
+		{"cyrillic homoglyphs", "Насос", []string{"hacoc"}},
+		{"mixed scripts", "НасоC", []string{"hacoc"}},
+		{"yo as e", "Ёж", []string{"eж"}},
+		{"non homoglyph letters kept", "Задвижка", []string{"зaдbижka"}},
+		{"punctuation splits words", "Насос Н-1", []string{"hacoc", "h", "1"}},
+		{"repeated separators", "  Р-1 //  ТК.2 ", []string{"p", "1", "tk", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher NodeMatcher
+		ref     string
+		node    string
+		want    float64
+	}{
+		{"exact same", ExactMatcher{}, "Насос 1", "Насос 1", 1},
+		{"exact case differs", ExactMatcher{}, "Насос 1", "насос 1", 0},
+		{"normalized homoglyphs", NormalizedMatcher{}, "Насос Н-1", "hacoc h1", 1},
+		{"normalized empty", NormalizedMatcher{}, "--", "", 0},
+		{"token same words", TokenMatcher{}, "Насос 1", "насос  1", 1},
+		{"token word order", TokenMatcher{}, "1 Насос", "Насос 1", 1},
+		{"token one of three", TokenMatcher{}, "Насос 1", "Насос 2", 1.0 / 3},
+		{"token punctuation splits", TokenMatcher{}, "Насос Н-1", "Насос Н1", 1.0 / 4},
+		{"token empty ref", TokenMatcher{}, "", "Насос", 0},
+		{"token no common", TokenMatcher{}, "Насос", "Задвижка", 0},
+		{"trigram same", TrigramMatcher{}, "abc", "ABC", 1},
+		{"trigram homoglyphs", TrigramMatcher{}, "Насос", "hacoc", 1},
+		{"trigram first letter", TrigramMatcher{}, "ab", "ac", 1.0 / 5},
+		{"trigram empty ref", TrigramMatcher{}, "", "abc", 0},
+		{"trigram no common", TrigramMatcher{}, "abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matcher.Score(tt.ref, tt.node); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%s.Score(%q, %q) = %v, want %v", tt.matcher.Name(), tt.ref, tt.node, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankNodes(t *testing.T) {
+	node := func(id uint, name string) Node {
+		return Node{Model: gorm.Model{ID: id}, Name: name}
+	}
+	nodes := []Node{
+		node(1, "Насос 2"),
+		node(2, "Задвижка"),
+		node(3, "насос 1"),
+		node(4, "Насос 1"),
+	}
+
+	tests := []struct {
+		name  string
+		ref   string
+		limit int
+		want  []NodeCandidate
+	}{
+		{
+			name: "sorted by score then name, zero scores dropped",
+			ref:  "Насос 1",
+			want: []NodeCandidate{
+				{ID: 4, Name: "Насос 1", Score: 1},
+				{ID: 3, Name: "насос 1", Score: 1},
+				{ID: 1, Name: "Насос 2", Score: 1.0 / 3},
+			},
+		},
+		{
+			name:  "limit",
+			ref:   "Насос 1",
+			limit: 2,
+			want: []NodeCandidate{
+				{ID: 4, Name: "Насос 1", Score: 1},
+				{ID: 3, Name: "насос 1", Score: 1},
+			},
+		},
+		{
+			name:  "limit above count",
+			ref:   "Задвижка",
+			limit: 5,
+			want:  []NodeCandidate{{ID: 2, Name: "Задвижка", Score: 1}},
+		},
+		{
+			name: "no match",
+			ref:  "Клапан",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankNodes(TokenMatcher{}, tt.ref, nodes, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RankNodes(%q, %d) = %+v, want %+v", tt.ref, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNodeMatcher(t *testing.T) {
+	for _, strategy := range []string{MatchExact, MatchNormalized, MatchToken, MatchTrigram} {
+		m, err := NewNodeMatcher(strategy)
+		if err != nil {
+			t.Fatalf("NewNodeMatcher(%q): %v", strategy, err)
+		}
+		if m.Name() != strategy {
+			t.Errorf("NewNodeMatcher(%q).Name() = %q", strategy, m.Name())
+		}
+	}
+	if _, err := NewNodeMatcher("soundex"); err == nil {
+		t.Error("NewNodeMatcher(\"soundex\") returned no error")
+	}
+}
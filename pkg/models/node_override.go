@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Виды ручных назначений узла
+const (
+	OverrideByTag     = "tag"      // По тэгу сигнала
+	OverrideByNodeRef = "node_ref" // По тексту колонки node листа сигналов
+)
+
+// Способы определения узла сигнала при синхронизации
+const (
+	NodeMatchExact    = "exact"
+	NodeMatchOverride = "override"
+	NodeMatchFuzzy    = "fuzzy"
+	NodeMatchCreated  = "created"
+	NodeMatchRejected = "rejected" // Сходство ниже минимального, сигнал не привязан
+)
+
+// NodeOverride - ручное назначение узла, применяемое до нечеткого сопоставления.
+// Узел хранится по имени: таблица узлов пересоздается при каждой синхронизации.
+type NodeOverride struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Kind      string    `gorm:"size:20;not null;uniqueIndex:idx_node_override" json:"kind"`
+	Key       string    `gorm:"size:255;not null;uniqueIndex:idx_node_override" json:"key"`
+	System    string    `gorm:"size:255;not null;default:'';uniqueIndex:idx_node_override" json:"system"` // Для node_ref: пусто - любая система
+	Node      string    `gorm:"size:255;not null" json:"node"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate проверяет вид назначения и обязательные поля
+func (o *NodeOverride) Validate() error {
+	o.Kind = strings.TrimSpace(o.Kind)
+	o.Key = strings.TrimSpace(o.Key)
+	o.System = strings.TrimSpace(o.System)
+	o.Node = strings.TrimSpace(o.Node)
+
+	if o.Kind != OverrideByTag && o.Kind != OverrideByNodeRef {
+		return fmt.Errorf("kind must be %q or %q", OverrideByTag, OverrideByNodeRef)
+	}
+	if o.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if o.Node == "" {
+		return fmt.Errorf("node is required")
+	}
+	if o.Kind == OverrideByTag {
+		o.System = ""
+	}
+	return nil
+}
+
+// NodeOverrides - назначения узлов для поиска при синхронизации
+type NodeOverrides struct {
+	byTag     map[string]NodeOverride
+	byNodeRef map[string]NodeOverride // Ключ: система + "\x00" + текст узла
+}
+
+// NewNodeOverrides строит индекс назначений
+func NewNodeOverrides(overrides []NodeOverride) *NodeOverrides {
+	idx := &NodeOverrides{
+		byTag:     make(map[string]NodeOverride),
+		byNodeRef: make(map[string]NodeOverride),
+	}
+	for _, o := range overrides {
+		switch o.Kind {
+		case OverrideByTag:
+			idx.byTag[o.Key] = o
+		case OverrideByNodeRef:
+			idx.byNodeRef[o.System+"\x00"+o.Key] = o
+		}
+	}
+	return idx
+}
+
+// Find возвращает назначение для сигнала: по тэгу, затем по тексту узла в системе,
+// затем по тексту узла для любой системы
+func (idx *NodeOverrides) Find(tag, system, nodeRef string) (NodeOverride, bool) {
+	if o, ok := idx.byTag[tag]; ok {
+		return o, true
+	}
+	if nodeRef == "" {
+		return NodeOverride{}, false
+	}
+	if o, ok := idx.byNodeRef[system+"\x00"+nodeRef]; ok {
+		return o, true
+	}
+	o, ok := idx.byNodeRef["\x00"+nodeRef]
+	return o, ok
+}
+
+// NodeCandidate - узел, похожий на текст из листа, и оценка сходства 0..1
+type NodeCandidate struct {
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// NodeMatch - результат определения узла сигнала при синхронизации.
+// Хранится до следующей синхронизации и не очищается при запуске.
+type NodeMatch struct {
+	ID         uint            `gorm:"primarykey" json:"id"`
+	SignalID   uint            `json:"signalId"`
+	SignalTag  string          `gorm:"size:255" json:"signalTag"`
+	System     string          `gorm:"size:255" json:"system"`
+	NodeRef    string          `json:"nodeRef"`                     // Текст колонки node листа
+	Node       string          `gorm:"size:255" json:"node"`        // Выбранный узел
+	Method     string          `gorm:"size:20;index" json:"method"` // exact, override, fuzzy, created
+	Score      float64         `json:"score"`
+	Candidates []NodeCandidate `gorm:"serializer:json" json:"candidates,omitempty"`
+}
+
+// NeedsReview сообщает, что узел выбран неуверенно: создан новый
+// или сходство ниже порога
+func (m NodeMatch) NeedsReview(threshold float64) bool {
+	switch m.Method {
+	case NodeMatchCreated, NodeMatchRejected:
+		return true
+	case NodeMatchFuzzy:
+		return m.Score < threshold
+	}
+	return false
+}
+
+// SheetNodeMatch - строка листа отчета о сопоставлении узлов
+type SheetNodeMatch struct {
+	Signal     string `gsheets:"signal"`
+	System     string `gsheets:"system"`
+	NodeRef    string `gsheets:"node_ref"`
+	Node       string `gsheets:"node"`
+	Method     string `gsheets:"method"`
+	Score      string `gsheets:"score"`
+	Candidates string `gsheets:"candidates"`
+	Review     string `gsheets:"review"`
+}
+
+// SheetRow возвращает строку листа отчета о сопоставлении узлов
+func (m NodeMatch) SheetRow(threshold float64) SheetNodeMatch {
+	var candidates []string
+	for _, c := range m.Candidates {
+		candidates = append(candidates, fmt.Sprintf("%s (%.2f)", c.Name, c.Score))
+	}
+	row := SheetNodeMatch{
+		Signal:     m.SignalTag,
+		System:     m.System,
+		NodeRef:    m.NodeRef,
+		Node:       m.Node,
+		Method:     m.Method,
+		Score:      fmt.Sprintf("%.2f", m.Score),
+		Candidates: strings.Join(candidates, ", "),
+	}
+	if m.NeedsReview(threshold) {
+		row.Review = "да"
+	}
+	return row
+}
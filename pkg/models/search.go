@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Виды результатов поиска
+const (
+	SearchSignal        = "signal"
+	SearchFunctionBlock = "functionblock"
+)
+
+// Фасеты поиска: имя фасета совпадает с параметром запроса
+var SearchFacets = []string{"kind", "system", "node", "signal_type", "cds_type", "product", "crate"}
+
+// Поля сортировки результатов поиска
+var SearchSorts = []string{"score", "tag", "name", "system", "node", "type"}
+
+// SearchQuery - параметры поиска по сигналам и ФБ
+type SearchQuery struct {
+	Text       string `form:"q"`
+	Kind       string `form:"kind"` // signal, functionblock или пусто - все
+	System     string `form:"system"`
+	Node       string `form:"node"`
+	SignalType string `form:"signal_type"`
+	CdsType    string `form:"cds_type"`
+	Product    string `form:"product"`
+	Crate      string `form:"crate"`
+	Sort       string `form:"sort"`  // score, tag, name, system, node, type
+	Order      string `form:"order"` // asc, desc
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+}
+
+// Normalize приводит параметры к допустимым значениям: страница с 1,
+// размер страницы 1..500 (по умолчанию 50), сортировка по релевантности при наличии текста
+func (q *SearchQuery) Normalize() {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = 50
+	}
+	if q.PageSize > 500 {
+		q.PageSize = 500
+	}
+	if q.Sort == "" || (q.Sort == "score" && q.Text == "") {
+		q.Sort = "tag"
+		if q.Text != "" {
+			q.Sort = "score"
+		}
+	}
+	q.Order = strings.ToLower(q.Order)
+	if q.Order != "asc" && q.Order != "desc" {
+		q.Order = "asc"
+		if q.Sort == "score" {
+			q.Order = "desc"
+		}
+	}
+}
+
+// Validate проверяет поле сортировки; вызывается после Normalize
+func (q *SearchQuery) Validate() error {
+	for _, sort := range SearchSorts {
+		if q.Sort == sort {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported sort field %q, expected one of %s", q.Sort, strings.Join(SearchSorts, ", "))
+}
+
+// FacetValue возвращает значение фильтра по имени фасета
+func (q *SearchQuery) FacetValue(facet string) string {
+	switch facet {
+	case "kind":
+		return q.Kind
+	case "system":
+		return q.System
+	case "node":
+		return q.Node
+	case "signal_type":
+		return q.SignalType
+	case "cds_type":
+		return q.CdsType
+	case "product":
+		return q.Product
+	case "crate":
+		return q.Crate
+	}
+	return ""
+}
+
+// SearchHit - найденный сигнал или ФБ
+type SearchHit struct {
+	Kind       string  `json:"kind"`
+	ID         uint    `json:"id"`
+	Tag        string  `json:"tag"`
+	Name       string  `json:"name"`
+	Comment    string  `json:"comment"`
+	Equipment  string  `json:"equipment"`
+	System     string  `json:"system"`
+	Node       string  `json:"node"`
+	SignalType string  `json:"signalType"`
+	CdsType    string  `json:"cdsType"`
+	Product    string  `json:"product"`
+	Crate      string  `json:"crate"`
+	Score      float64 `json:"score"`
+}
+
+// FacetCount - значение фасета и число результатов с ним
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// SearchResult - страница результатов поиска и фасеты
+type SearchResult struct {
+	Hits     []SearchHit             `json:"hits"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+	Facets   map[string][]FacetCount `json:"facets"`
+}
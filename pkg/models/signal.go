@@ -58,6 +58,11 @@ type Signal struct {
 	TON       *float64 `gorm:"type:decimal(9,3)"` // Timer On Delay
 	TOF       *float64 `gorm:"type:decimal(9,3)"` // Timer Off Delay
 
+	// Тип модуля из каталога (заполняется при синхронизации по Module)
+	ModuleType       string `gorm:"size:100"`
+	ModuleChannels   int
+	ModuleAddressing string `gorm:"size:100"`
+
 	// Метаданные
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
@@ -85,15 +90,17 @@ func (s *Signal) FromAI(ai AI) {
 	s.Comment = ai.Comment
 
 	// Копируем специфичные поля AI
-	s.Value = ai.YMIN // Или другое начальное значение
-	s.RangeMin = &ai.YMIN
-	s.RangeMax = &ai.YMAX
+	if ai.YMIN != nil {
+		s.Value = *ai.YMIN // Или другое начальное значение
+	}
+	s.RangeMin = ai.YMIN
+	s.RangeMax = ai.YMAX
 	s.Unit = &ai.Unit
 	s.Sign = &ai.Sign
-	s.WarningLow = &ai.WL
-	s.WarningHigh = &ai.WH
-	s.AlarmLow = &ai.AL
-	s.AlarmHigh = &ai.AH
+	s.WarningLow = ai.WL
+	s.WarningHigh = ai.WH
+	s.AlarmLow = ai.AL
+	s.AlarmHigh = ai.AH
 	s.Format = &ai.Format
 	s.Filter = &ai.Filter
 }
@@ -120,11 +127,11 @@ func (s *Signal) FromDI(di DI) {
 	s.Comment = di.Comment
 
 	// Копируем специфичные поля DI
-	if di.TON > 0 {
-		s.TON = &di.TON
+	if di.TON != nil && *di.TON > 0 {
+		s.TON = di.TON
 	}
-	if di.TOF > 0 {
-		s.TOF = &di.TOF
+	if di.TOF != nil && *di.TOF > 0 {
+		s.TOF = di.TOF
 	}
 	s.Category = &di.Category
 	s.Inversion = &di.Inversion
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SignalInput - сигнал в запросе API; поля соответствуют колонкам листов DI/AI/DQ/AQ
+type SignalInput struct {
+	Tag         string `json:"tag"`
+	SignalType  string `json:"signalType"`
+	System      string `json:"system"`
+	Product     string `json:"product"`
+	Node        string `json:"node"`
+	Equipment   string `json:"equipment"`
+	Name        string `json:"name"`
+	Module      string `json:"module"`
+	Channel     string `json:"channel"`
+	Crate       string `json:"crate"`
+	Place       string `json:"place"`
+	Property    string `json:"property"`
+	Address     string `json:"address"`
+	ModbusAddr  string `json:"modbusAddr"`
+	FB          string `json:"fb"`
+	CheckStatus string `json:"check"`
+	Comment     string `json:"comment"`
+
+	// AI; null - значение не задано (пустая ячейка листа)
+	RangeMin    *float64 `json:"rangeMin"`
+	RangeMax    *float64 `json:"rangeMax"`
+	Unit        string   `json:"unit"`
+	Sign        string   `json:"sign"`
+	WarningLow  *float64 `json:"warningLow"`
+	WarningHigh *float64 `json:"warningHigh"`
+	AlarmLow    *float64 `json:"alarmLow"`
+	AlarmHigh   *float64 `json:"alarmHigh"`
+	Format      string   `json:"format"`
+	Filter      string   `json:"filter"`
+
+	// DI
+	Category  string   `json:"category"`
+	Inversion string   `json:"inversion"`
+	TON       *float64 `json:"ton"`
+	TOF       *float64 `json:"tof"`
+}
+
+// Validate проверяет обязательные поля по правилам загрузки из листа
+func (in *SignalInput) Validate() error {
+	in.Tag = strings.TrimSpace(in.Tag)
+	in.SignalType = strings.ToUpper(strings.TrimSpace(in.SignalType))
+
+	var problems []string
+	if in.Tag == "" {
+		problems = append(problems, "tag is required")
+	}
+	switch in.SignalType {
+	case "DI", "AI", "DQ", "AQ":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown signal type %q", in.SignalType))
+	}
+	if strings.TrimSpace(in.System) == "" {
+		problems = append(problems, "system is required")
+	}
+	if strings.TrimSpace(in.Product) == "" {
+		problems = append(problems, "product is required")
+	}
+	if in.SignalType == "AI" && in.RangeMin != nil && in.RangeMax != nil && *in.RangeMin >= *in.RangeMax {
+		problems = append(problems, "rangeMin must be less than rangeMax")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (in *SignalInput) base() Base {
+	return Base{
+		Tag:         in.Tag,
+		System:      in.System,
+		Equipment:   in.Equipment,
+		Name:        in.Name,
+		Product:     in.Product,
+		CheckStatus: in.CheckStatus,
+		FB:          in.FB,
+		Comment:     in.Comment,
+		Module:      in.Module,
+		Channel:     in.Channel,
+		Crate:       in.Crate,
+		Place:       in.Place,
+		Property:    in.Property,
+		Adr:         in.Address,
+		ModbusAddr:  in.ModbusAddr,
+		NodeID:      in.Node,
+	}
+}
+
+// SheetRow возвращает строку листа типа сигнала (DI, AI, DQ или AQ)
+func (in *SignalInput) SheetRow() interface{} {
+	switch in.SignalType {
+	case "DI":
+		return DI{
+			Base:      in.base(),
+			Category:  in.Category,
+			Inversion: in.Inversion,
+			TON:       in.TON,
+			TOF:       in.TOF,
+		}
+	case "AI":
+		return AI{
+			Base:   in.base(),
+			YMIN:   in.RangeMin,
+			YMAX:   in.RangeMax,
+			Unit:   in.Unit,
+			Sign:   in.Sign,
+			WL:     in.WarningLow,
+			WH:     in.WarningHigh,
+			AL:     in.AlarmLow,
+			AH:     in.AlarmHigh,
+			Format: in.Format,
+			Filter: in.Filter,
+		}
+	case "AQ":
+		return AQ{Base: in.base()}
+	default:
+		return DQ{Base: in.base()}
+	}
+}
+
+// ToSignal формирует сигнал так же, как при загрузке строки листа
+func (in *SignalInput) ToSignal() Signal {
+	var signal Signal
+	switch row := in.SheetRow().(type) {
+	case DI:
+		signal.FromDI(row)
+	case AI:
+		signal.FromAI(row)
+	case AQ:
+		signal.FromAQ(row)
+	case DQ:
+		signal.FromDQ(row)
+	}
+	return signal
+}
+
+// ToInput возвращает поля сигнала в виде запроса API
+func (s *Signal) ToInput() SignalInput {
+	in := SignalInput{
+		Tag:         s.Tag,
+		SignalType:  s.SignalType,
+		System:      systemName(s),
+		Product:     productName(s),
+		Node:        nodeName(s),
+		Equipment:   s.Equipment,
+		Name:        s.Name,
+		Module:      s.Module,
+		Channel:     s.Channel,
+		Crate:       s.Crate,
+		Place:       s.Place,
+		Property:    s.Property,
+		Address:     s.Address,
+		ModbusAddr:  s.ModbusAddr,
+		FB:          s.FB,
+		CheckStatus: s.CheckStatus,
+		Comment:     s.Comment,
+	}
+	str := func(v *string) string {
+		if v == nil {
+			return ""
+		}
+		return *v
+	}
+	in.RangeMin, in.RangeMax = s.RangeMin, s.RangeMax
+	in.WarningLow, in.WarningHigh = s.WarningLow, s.WarningHigh
+	in.AlarmLow, in.AlarmHigh = s.AlarmLow, s.AlarmHigh
+	in.Unit, in.Sign, in.Format, in.Filter = str(s.Unit), str(s.Sign), str(s.Format), str(s.Filter)
+	in.Category, in.Inversion = str(s.Category), str(s.Inversion)
+	in.TON, in.TOF = s.TON, s.TOF
+	return in
+}
+
+// productName возвращает наименование изделия сигнала (колонка product листа)
+func productName(s *Signal) string {
+	if s.Product != nil {
+		return s.Product.Name
+	}
+	return s.ProductRef
+}
+
+// SignalFilter - фильтр и страница списка сигналов
+type SignalFilter struct {
+	System     string `form:"system"`
+	Node       string `form:"node"`
+	SignalType string `form:"type"`
+	Product    string `form:"product"`
+	Crate      string `form:"crate"`
+	Module     string `form:"module"`
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+}
@@ -0,0 +1,349 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// TemplateFuncs возвращает общий набор функций, доступный во всех шаблонах
+// (адрес сигнала, вызов ST, OMX и OPC).
+//
+// Строки:
+//
+//	upper, lower, trim            — регистр и пробелы
+//	replace s old new             — замена подстроки
+//	regex_replace s pattern repl  — замена по регулярному выражению
+//	pad_left v n [pad]            — дополнение слева до длины n (по умолчанию "0")
+//	pad_right v n [pad]           — дополнение справа до длины n (по умолчанию " ")
+//	format_number v n             — дополнение числа нулями слева до длины n
+//	default def v                 — def, если v пустое (nil, "", 0, nil-указатель)
+//
+// Арифметика (аргументы — числа, строки с числами или указатели на числа):
+//
+//	add, sub, mul, div a b        — результат float64
+//	int v                         — округление до целого (T#{{int (mul . 1000)}}MS)
+//	decrement v                   — v-1 (номер канала с единицы в индекс с нуля)
+//
+// Экранирование:
+//
+//	xml_escape s                  — экранирование для XML (OMX, OPC)
+//	st_escape s                   — экранирование для строкового литерала ST ('...')
+//	st_comment s                  — безопасный текст для комментария ST (* ... *)
+//
+// Связи:
+//
+//	attr fb funcAttr              — переменная ФБ по атрибуту (*FBVariable или nil)
+//	signal_field fb funcAttr name — поле сигнала, привязанного к атрибуту
+//	node_name v                   — имя узла ФБ или сигнала
+//	system_name v                 — имя системы ФБ или сигнала
+//	product_tag v                 — тэг изделия сигнала
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":         strings.ToUpper,
+		"lower":         strings.ToLower,
+		"trim":          strings.TrimSpace,
+		"replace":       strings.ReplaceAll,
+		"regex_replace": regexReplace,
+		"pad_left":      padLeft,
+		"pad_right":     padRight,
+		"format_number": formatNumber,
+		"default":       defaultValue,
+		"add":           func(a, b interface{}) (float64, error) { return arith(a, b, '+') },
+		"sub":           func(a, b interface{}) (float64, error) { return arith(a, b, '-') },
+		"mul":           func(a, b interface{}) (float64, error) { return arith(a, b, '*') },
+		"div":           func(a, b interface{}) (float64, error) { return arith(a, b, '/') },
+		"int":           toInt,
+		"decrement":     decrementString,
+		"xml_escape":    xmlEscape,
+		"st_escape":     stEscape,
+		"st_comment":    stComment,
+		"attr":          variableByAttr,
+		"signal_field":  signalField,
+		"node_name":     nodeName,
+		"system_name":   systemName,
+		"product_tag":   productTag,
+	}
+}
+
+// newTemplate создает шаблон с подключенными TemplateFuncs
+func newTemplate(name string) *template.Template {
+	return template.New(name).Funcs(TemplateFuncs())
+}
+
+// decrementString принимает только целый номер канала: пустой или дробный
+// номер - ошибка шаблона, а не адрес с каналом -1 или 0
+func decrementString(channel interface{}) (string, error) {
+	num, err := strconv.Atoi(strings.TrimSpace(toString(channel)))
+	if err != nil {
+		return "", fmt.Errorf("invalid channel number: %v", err)
+	}
+	return strconv.Itoa(num - 1), nil
+}
+
+func formatNumber(input interface{}, length int) string {
+	return padLeft(input, length)
+}
+
+func padLeft(v interface{}, length int, pad ...string) string {
+	s, p := toString(v), "0"
+	if len(pad) > 0 && pad[0] != "" {
+		p = pad[0]
+	}
+	for len([]rune(s)) < length {
+		s = p + s
+	}
+	return s
+}
+
+func padRight(v interface{}, length int, pad ...string) string {
+	s, p := toString(v), " "
+	if len(pad) > 0 && pad[0] != "" {
+		p = pad[0]
+	}
+	for len([]rune(s)) < length {
+		s += p
+	}
+	return s
+}
+
+// regexCache - скомпилированные выражения regex_replace: шаблон выполняется для каждого сигнала
+var regexCache sync.Map
+
+func regexReplace(s, pattern, repl string) (string, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp).ReplaceAllString(s, repl), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	regexCache.Store(pattern, re)
+	return re.ReplaceAllString(s, repl), nil
+}
+
+func defaultValue(def, v interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil() || isEmpty(rv.Elem().Interface())
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+func arith(a, b interface{}, op rune) (float64, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	switch op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	default:
+		if y == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return x / y, nil
+	}
+}
+
+func toFloat(v interface{}) (float64, error) {
+	if v == nil {
+		return 0, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return 0, nil
+		}
+		return toFloat(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		s := strings.TrimSpace(strings.Replace(rv.String(), ",", ".", 1))
+		if s == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", rv.String())
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
+
+func toInt(v interface{}) (int, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(f)), nil
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		return toString(rv.Elem().Interface())
+	}
+	return fmt.Sprint(v)
+}
+
+func xmlEscape(v interface{}) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(toString(v))); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// stEscape экранирует строку для литерала ST по IEC 61131-3 ($$, $', $N, $T)
+func stEscape(v interface{}) string {
+	return strings.NewReplacer(
+		"$", "$$",
+		"'", "$'",
+		"\r\n", "$N",
+		"\n", "$N",
+		"\t", "$T",
+	).Replace(toString(v))
+}
+
+// stComment убирает из текста последовательности, закрывающие комментарий ST
+func stComment(v interface{}) string {
+	return strings.NewReplacer("*)", "* )", "(*", "( *").Replace(toString(v))
+}
+
+func variableByAttr(fb interface{}, funcAttr string) *FBVariable {
+	var block *FunctionBlock
+	switch v := fb.(type) {
+	case *FunctionBlock:
+		block = v
+	case FunctionBlock:
+		block = &v
+	}
+	if block == nil {
+		return nil
+	}
+	for i := range block.Variables {
+		if block.Variables[i].FuncAttr == funcAttr {
+			return &block.Variables[i]
+		}
+	}
+	return nil
+}
+
+func signalField(fb interface{}, funcAttr, field string) (interface{}, error) {
+	v := variableByAttr(fb, funcAttr)
+	if v == nil {
+		return "", nil
+	}
+	f := reflect.ValueOf(v.Signal).FieldByName(field)
+	if !f.IsValid() {
+		return nil, fmt.Errorf("signal has no field %q", field)
+	}
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return "", nil
+		}
+		f = f.Elem()
+	}
+	return f.Interface(), nil
+}
+
+func nodeName(v interface{}) string {
+	switch x := v.(type) {
+	case *FunctionBlock:
+		if x != nil && x.Node != nil {
+			return x.Node.Name
+		}
+		if x != nil {
+			return x.NodeRef
+		}
+	case *Signal:
+		if x != nil && x.Node != nil {
+			return x.Node.Name
+		}
+		if x != nil {
+			return x.NodeRef
+		}
+	case Signal:
+		return nodeName(&x)
+	case FunctionBlock:
+		return nodeName(&x)
+	}
+	return ""
+}
+
+func systemName(v interface{}) string {
+	switch x := v.(type) {
+	case *FunctionBlock:
+		if x != nil && x.System != nil {
+			return x.System.Name
+		}
+	case *Signal:
+		if x != nil && x.System != nil {
+			return x.System.Name
+		}
+		if x != nil {
+			return x.SystemRef
+		}
+	case Signal:
+		return systemName(&x)
+	case FunctionBlock:
+		return systemName(&x)
+	}
+	return ""
+}
+
+func productTag(v interface{}) string {
+	switch x := v.(type) {
+	case *Signal:
+		if x != nil && x.Product != nil {
+			return x.Product.Tag
+		}
+		if x != nil {
+			return x.ProductRef
+		}
+	case Signal:
+		return productTag(&x)
+	}
+	return ""
+}
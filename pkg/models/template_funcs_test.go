@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	value := 2.5
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    interface{}
+		want    string
+		wantErr string
+	}{
+		{"decrement", `{{decrement .}}`, "5", "4", ""},
+		{"decrement spaces", `{{decrement .}}`, " 1 ", "0", ""},
+		{"decrement int", `{{decrement .}}`, 3, "2", ""},
+		{"decrement empty", `{{decrement .}}`, "", "", "invalid channel number"},
+		{"decrement fraction", `{{decrement .}}`, "1.5", "", "invalid channel number"},
+		{"pad_left default zero", `{{pad_left . 4}}`, "7", "0007", ""},
+		{"pad_left custom", `{{pad_left . 3 "_"}}`, "7", "__7", ""},
+		{"pad_left longer", `{{pad_left . 2}}`, "12345", "12345", ""},
+		{"pad_left runes", `{{pad_left . 4}}`, "ДИ", "00ДИ", ""},
+		{"pad_right default space", `{{pad_right . 4}}|`, "ab", "ab  |", ""},
+		{"pad_right custom", `{{pad_right . 4 "."}}`, "ab", "ab..", ""},
+		{"format_number", `{{format_number . 3}}`, 5, "005", ""},
+		{"format_number pointer", `{{format_number . 3}}`, &value, "2.5", ""},
+		{"regex_replace", `{{regex_replace . "[0-9]+" "N"}}`, "AI12_3", "AIN_N", ""},
+		{"regex_replace groups", `{{regex_replace . "^(\\w+)_(\\w+)$" "$2.$1"}}`, "PT_101", "101.PT", ""},
+		{"regex_replace invalid", `{{regex_replace . "(" ""}}`, "x", "", "invalid regex"},
+		{"xml_escape", `{{xml_escape .}}`, `a<b & "c"`, "a&lt;b &amp; &#34;c&#34;", ""},
+		{"xml_escape nil pointer", `{{xml_escape .}}`, (*string)(nil), "", ""},
+		{"st_escape", `{{st_escape .}}`, "it's $5\r\nnext\tcol", "it$'s $$5$Nnext$Tcol", ""},
+		{"st_comment", `{{st_comment .}}`, "(* a *)", "( * a * )", ""},
+		{"div", `{{div . 4}}`, "10", "2.5", ""},
+		{"div comma", `{{div . 2}}`, "1,5", "0.75", ""},
+		{"div by zero", `{{div . 0}}`, 1, "", "division by zero"},
+		{"div by empty", `{{div 1 .}}`, "", "", "division by zero"},
+		{"int", `{{int (mul . 1000)}}`, "0.0015", "2", ""},
+		{"default empty", `{{default "x" .}}`, "", "x", ""},
+		{"default set", `{{default "x" .}}`, "y", "y", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := newTemplate(tt.name).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.tmpl, err)
+			}
+			var b strings.Builder
+			err = tmpl.Execute(&b, tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%s with %v: error = %v, want %q", tt.tmpl, tt.data, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s with %v: %v", tt.tmpl, tt.data, err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("%s with %v = %q, want %q", tt.tmpl, tt.data, got, tt.want)
+			}
+		})
+	}
+}
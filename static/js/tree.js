@@ -18,8 +18,119 @@ document.addEventListener('DOMContentLoaded', function() {
             initCodeHighlighting();
             initSpoilers();
         });
+    initSearch();
 });
 
+// Поиск по сигналам и ФБ с фасетами
+const searchFacetLabels = {
+    kind: 'Вид',
+    system: 'Система',
+    node: 'Узел',
+    signal_type: 'Тип сигнала',
+    cds_type: 'Тип ФБ',
+    product: 'Изделие',
+    crate: 'Крейт'
+};
+const searchKindLabels = { signal: 'сигнал', functionblock: 'ФБ' };
+const searchState = { filters: {}, page: 1, hits: [] };
+
+function initSearch() {
+    const input = document.getElementById('searchInput');
+    if (!input) return;
+
+    let timer;
+    input.addEventListener('input', () => {
+        clearTimeout(timer);
+        timer = setTimeout(() => runSearch(1), 300);
+    });
+    document.getElementById('searchSort').addEventListener('change', () => runSearch(1));
+    document.getElementById('searchFacets').addEventListener('change', e => {
+        if (!e.target.dataset.facet) return;
+        searchState.filters[e.target.dataset.facet] = e.target.value;
+        runSearch(1);
+    });
+    document.getElementById('searchResults').addEventListener('click', e => {
+        if (e.target.closest('.search-more')) {
+            runSearch(searchState.page + 1);
+            return;
+        }
+        const hit = e.target.closest('.search-hit');
+        if (hit) loadDetails(hit.dataset.kind, hit.dataset.id);
+    });
+}
+
+function searchActive() {
+    const text = document.getElementById('searchInput').value.trim();
+    return text !== '' || Object.values(searchState.filters).some(v => v);
+}
+
+function runSearch(page) {
+    const results = document.getElementById('searchResults');
+    const facets = document.getElementById('searchFacets');
+    const tree = document.getElementById('tree');
+    if (!searchActive()) {
+        results.innerHTML = '';
+        facets.innerHTML = '';
+        tree.style.display = '';
+        return;
+    }
+
+    const params = new URLSearchParams({ page: page });
+    const text = document.getElementById('searchInput').value.trim();
+    if (text) params.set('q', text);
+    const sort = document.getElementById('searchSort').value;
+    if (sort) params.set('sort', sort);
+    for (const [facet, value] of Object.entries(searchState.filters)) {
+        if (value) params.set(facet, value);
+    }
+
+    fetch(`/api/search?${params}`)
+        .then(response => response.json().then(data => {
+            if (!response.ok) throw new Error(data.error || `HTTP ${response.status}`);
+            return data;
+        }))
+        .then(data => {
+            searchState.page = data.page;
+            searchState.hits = page > 1 ? searchState.hits.concat(data.hits) : data.hits;
+            tree.style.display = 'none';
+            facets.innerHTML = renderSearchFacets(data.facets);
+            results.innerHTML = renderSearchHits(searchState.hits, data.total);
+        })
+        .catch(error => {
+            results.innerHTML = `<div class="alert alert-danger">Ошибка поиска: ${escapeHtml(error.message)}</div>`;
+        });
+}
+
+function renderSearchFacets(facets) {
+    return Object.keys(searchFacetLabels).map(facet => {
+        const values = (facets && facets[facet]) || [];
+        const selected = searchState.filters[facet] || '';
+        if (!values.length && !selected) return '';
+        const options = values.map(f => {
+            const label = facet === 'kind' ? (searchKindLabels[f.value] || f.value) : f.value;
+            return `<option value="${escapeHtml(f.value)}" ${f.value === selected ? 'selected' : ''}>${escapeHtml(label)} (${f.count})</option>`;
+        }).join('');
+        return `<select class="form-select form-select-sm" data-facet="${facet}">
+            <option value="">${searchFacetLabels[facet]}: все</option>${options}
+        </select>`;
+    }).join('');
+}
+
+function renderSearchHits(hits, total) {
+    if (!hits.length) return '<div class="text-muted">Ничего не найдено</div>';
+    const items = hits.map(hit => {
+        const meta = [searchKindLabels[hit.kind], hit.signalType || hit.cdsType, hit.system, hit.node]
+            .filter(Boolean).map(v => escapeHtml(String(v))).join(' · ');
+        return `<div class="search-hit" data-kind="${hit.kind}" data-id="${hit.id}">
+            <strong>${escapeHtml(hit.tag)}</strong> ${escapeHtml(hit.name || '')}
+            <div class="search-hit-meta">${meta}</div>
+        </div>`;
+    }).join('');
+    const more = hits.length < total
+        ? `<button class="btn btn-link btn-sm search-more">Ещё (${total - hits.length})</button>` : '';
+    return `<div class="text-muted small mb-1">Найдено: ${total}</div>${items}${more}`;
+}
+
 // Добавьте проверку данных при рендеринге
 function renderTree(data) {
     console.log("Tree data:", JSON.stringify(data, null, 2)); // Проверьте структуру
@@ -216,6 +327,7 @@ function renderDetailsTable(data, type) {
     // Основные поля
     for (const [key, value] of Object.entries(data)) {
         if (value && typeof value === 'object' && key === 'call') continue;
+        if (key === 'missingIn' || key === 'missingOut') continue;
         html += `<tr>
             <td><strong>${key}</strong></td>
             <td>${value}</td>
@@ -239,6 +351,12 @@ function renderDetailsTable(data, type) {
             break;
             
         case 'functionblock':
+            const missing = [...(data.missingIn || []), ...(data.missingOut || [])];
+            if (missing.length > 0) {
+                html += `<div class="alert alert-warning">
+                    Не подключены обязательные входы/выходы: ${missing.join(', ')}
+                </div>`;
+            }
             if (data.variables && data.variables.length > 0) {
                 html += `<h4>Переменные</h4>
                 <table class="details-table">
@@ -252,7 +370,7 @@ function renderDetailsTable(data, type) {
                 data.variables.forEach(v => {
                     html += `<tr>
                         <td>${v.direction}</td>
-                        <td>${v.signalTag}</td>
+                        <td>${v.signalTag || `${v.funcAttr} ← ${v.source}${v.sourcePin ? '.' + v.sourcePin : ''}`}</td>
                     </tr>`;
                 });
                 html += `</tbody></table>`;
@@ -266,6 +384,15 @@ function renderDetailsTable(data, type) {
                     </div>
                 </div>`;
             }
+            if (data.init) {
+                html += `
+                <div class="spoiler">
+                    <button class="spoiler-toggle">Показать инициализацию параметров</button>
+                    <div class="spoiler-content" style="display:none;">
+                        <pre class="line-numbers"><code class="language-st">${escapeHtml(data.init)}</code></pre>
+                    </div>
+                </div>`;
+            }
             if (data.omx) {
                 html += `
                 <div class="spoiler">